@@ -0,0 +1,93 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ErrInvalidAge is returned when the OlderThan or NewerThan of a Target is negative, or when they
+// describe a window of time which no object could be created in.
+var ErrInvalidAge = errors.New("invalid age filter")
+
+// ageFilter evaluates the OlderThan and NewerThan filters of a Target against the creation
+// timestamps of objects.
+type ageFilter struct {
+	olderThan *time.Duration
+	newerThan *time.Duration
+}
+
+// newAgeFilter checks the durations, and returns nil if neither of them is set.
+func newAgeFilter(olderThan, newerThan *metav1.Duration) (*ageFilter, error) {
+	if olderThan == nil && newerThan == nil {
+		return nil, nil //nolint:nilnil // a nil filter is valid, and matches everything
+	}
+
+	if errs := validateAgeFilter(olderThan, newerThan, nil); len(errs) != 0 {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAge, errs.ToAggregate())
+	}
+
+	filter := &ageFilter{}
+
+	// The durations are copied, so that the filter is not changed along with the Target.
+	if olderThan != nil {
+		older := olderThan.Duration
+		filter.olderThan = &older
+	}
+
+	if newerThan != nil {
+		newer := newerThan.Duration
+		filter.newerThan = &newer
+	}
+
+	return filter, nil
+}
+
+// matches returns whether an object created at the given time satisfies the filter, at the time
+// `now`. A nil filter matches everything.
+func (f *ageFilter) matches(created metav1.Time, now time.Time) bool {
+	if f == nil {
+		return true
+	}
+
+	age := now.Sub(created.Time)
+
+	if f.olderThan != nil && age <= *f.olderThan {
+		return false
+	}
+
+	if f.newerThan != nil && age >= *f.newerThan {
+		return false
+	}
+
+	return true
+}
+
+// validateAgeFilter checks that the durations are not negative, and that when both are set, the
+// NewerThan is longer than the OlderThan. All problems are returned, with paths relative to the
+// given fldPath (the path of the Target).
+func validateAgeFilter(olderThan, newerThan *metav1.Duration, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if olderThan != nil && olderThan.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("olderThan"), olderThan.Duration.String(),
+			"must not be negative"))
+	}
+
+	if newerThan != nil && newerThan.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("newerThan"), newerThan.Duration.String(),
+			"must not be negative"))
+	}
+
+	if len(allErrs) == 0 && olderThan != nil && newerThan != nil && newerThan.Duration <= olderThan.Duration {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("newerThan"), newerThan.Duration.String(),
+			"must be longer than olderThan, otherwise no objects can match"))
+	}
+
+	return allErrs
+}
@@ -0,0 +1,136 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ErrInvalidAnnotationSelector is returned when an AnnotationSelector is malformed.
+var ErrInvalidAnnotationSelector = errors.New("invalid annotation selector")
+
+// annotationMatcher evaluates an AnnotationSelector. Unlike a labels.Selector, it does not require
+// the values to be valid label values, since annotations can have arbitrary values.
+type annotationMatcher struct {
+	requirements []metav1.LabelSelectorRequirement
+}
+
+// newAnnotationMatcher checks the AnnotationSelector, and combines its MatchLabels and
+// MatchExpressions into one list of requirements. A nil selector results in a nil matcher, which
+// matches everything.
+func newAnnotationMatcher(sel *metav1.LabelSelector) (*annotationMatcher, error) {
+	if sel == nil {
+		return nil, nil //nolint:nilnil // a nil matcher is valid, and matches everything
+	}
+
+	if errs := validateAnnotationSelector(sel, field.NewPath("annotationSelector")); len(errs) != 0 {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAnnotationSelector, errs.ToAggregate())
+	}
+
+	reqs := make([]metav1.LabelSelectorRequirement, 0, len(sel.MatchLabels)+len(sel.MatchExpressions))
+
+	for _, key := range sortedKeys(sel.MatchLabels) {
+		reqs = append(reqs, metav1.LabelSelectorRequirement{
+			Key:      key,
+			Operator: metav1.LabelSelectorOpIn,
+			Values:   []string{sel.MatchLabels[key]},
+		})
+	}
+
+	return &annotationMatcher{requirements: append(reqs, sel.MatchExpressions...)}, nil
+}
+
+// matches returns whether the annotations satisfy all of the requirements. A nil matcher matches
+// everything.
+func (m *annotationMatcher) matches(annotations map[string]string) bool {
+	if m == nil {
+		return true
+	}
+
+	for _, req := range m.requirements {
+		val, found := annotations[req.Key]
+
+		var matched bool
+
+		switch req.Operator {
+		case metav1.LabelSelectorOpIn:
+			matched = found && slices.Contains(req.Values, val)
+		case metav1.LabelSelectorOpNotIn:
+			matched = !found || !slices.Contains(req.Values, val)
+		case metav1.LabelSelectorOpExists:
+			matched = found
+		case metav1.LabelSelectorOpDoesNotExist:
+			matched = !found
+		}
+
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateAnnotationSelector checks that the keys and operators in the AnnotationSelector are
+// well-formed, and that the values agree with the operators. The values themselves are not
+// restricted. All problems are returned, with paths relative to the given fldPath.
+func validateAnnotationSelector(sel *metav1.LabelSelector, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if sel == nil {
+		return allErrs
+	}
+
+	for _, key := range sortedKeys(sel.MatchLabels) {
+		for _, msg := range validation.IsQualifiedName(key) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("matchLabels"), key, msg))
+		}
+	}
+
+	for i, req := range sel.MatchExpressions {
+		reqPath := fldPath.Child("matchExpressions").Index(i)
+
+		for _, msg := range validation.IsQualifiedName(req.Key) {
+			allErrs = append(allErrs, field.Invalid(reqPath.Child("key"), req.Key, msg))
+		}
+
+		switch req.Operator {
+		case metav1.LabelSelectorOpIn, metav1.LabelSelectorOpNotIn:
+			if len(req.Values) == 0 {
+				allErrs = append(allErrs, field.Required(reqPath.Child("values"),
+					"must be specified when `operator` is 'In' or 'NotIn'"))
+			}
+		case metav1.LabelSelectorOpExists, metav1.LabelSelectorOpDoesNotExist:
+			if len(req.Values) != 0 {
+				allErrs = append(allErrs, field.Forbidden(reqPath.Child("values"),
+					"may not be specified when `operator` is 'Exists' or 'DoesNotExist'"))
+			}
+		default:
+			allErrs = append(allErrs, field.NotSupported(reqPath.Child("operator"), req.Operator, []string{
+				string(metav1.LabelSelectorOpIn), string(metav1.LabelSelectorOpNotIn),
+				string(metav1.LabelSelectorOpExists), string(metav1.LabelSelectorOpDoesNotExist),
+			}))
+		}
+	}
+
+	return allErrs
+}
+
+// sortedKeys returns the keys of the map in order, so that the results which depend on them are
+// consistent.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
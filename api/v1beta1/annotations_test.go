@@ -0,0 +1,60 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetNamespacesAnnotationSelector(t *testing.T) {
+	t.Parallel()
+
+	namespaces := make([]runtime.Object, 0, len(sampleNames))
+	for _, name := range sampleNames {
+		namespaces = append(namespaces, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: map[string]string{"owner": "team-" + name[:1]},
+		}})
+	}
+
+	cl := fake.NewClientBuilder().WithRuntimeObjects(namespaces...).Build()
+
+	tests := map[string]struct {
+		sel  NamespaceSelector
+		want []string
+	}{
+		"annotation selector with an include": {
+			sel: NamespaceSelector{
+				Include:            []NonEmptyString{"*"},
+				AnnotationSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"owner": "team-b"}},
+			},
+			want: []string{"bar", "baz", "boo"},
+		},
+		"annotation selector alone matches nothing": {
+			sel: NamespaceSelector{
+				AnnotationSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"owner": "team-b"}},
+			},
+			want: []string{},
+		},
+	}
+
+	for name, tcase := range tests {
+		got, err := tcase.sel.GetNamespaces(context.TODO(), cl)
+		if err != nil {
+			t.Errorf("Unexpected error '%v', in test '%v'", err, name)
+		}
+
+		less := func(a, b string) bool { return a < b }
+		if diff := cmp.Diff(tcase.want, got, cmpopts.SortSlices(less)); diff != "" {
+			t.Errorf("Mismatch in test '%v': %v", name, diff)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/google/cel-go/cel"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//+kubebuilder:object:generate=false
+
+// CompiledTarget is a Target whose selectors and patterns have been parsed ahead of time, so that
+// it can be evaluated many times without repeating that work. It produces the same results as the
+// Target it was compiled from. A CompiledTarget is not modified after it is created, so it can be
+// safely shared between goroutines; for example, it could be cached for each generation of a
+// policy.
+type CompiledTarget struct {
+	target            Target
+	labelSel          labels.Selector
+	annotations       *annotationMatcher
+	fieldSel          fields.Selector
+	names             nameMatcher
+	owners            *ownerMatcher
+	age               *ageFilter
+	requirements      []compiledFieldRequirement
+	expression        cel.Program
+	namespaceSelector *CompiledNamespaceSelector
+}
+
+// Compile parses the selectors and patterns in the Target, returning a CompiledTarget with the
+// same behavior. An error is returned if the LabelSelector, the AnnotationSelector, the
+// FieldSelector, the owner or age filters, the FieldRequirements, or the Expression (including
+// those in the NamespaceSelector) are malformed. To keep the same behavior as the Target, a malformed
+// Include or Exclude pattern is only reported when it is evaluated; use `Validate` to find those
+// problems ahead of time.
+func (t Target) Compile() (*CompiledTarget, error) {
+	compiled := &CompiledTarget{target: *t.DeepCopy()}
+
+	// Everything is built from the copy, so that later changes to the Target (including to the
+	// values behind its pointers and slices) do not affect the CompiledTarget.
+	src := &compiled.target
+
+	if src.NamespaceSelector != nil {
+		nsSel, err := src.NamespaceSelector.Compile()
+		if err != nil {
+			return nil, err
+		}
+
+		compiled.namespaceSelector = nsSel
+	}
+
+	nonNilSel := src.LabelSelector
+	if nonNilSel == nil { // override it to be empty if it is nil
+		nonNilSel = &metav1.LabelSelector{}
+	}
+
+	var err error
+
+	compiled.labelSel, err = metav1.LabelSelectorAsSelector(nonNilSel)
+	if err != nil {
+		return nil, err
+	}
+
+	compiled.annotations, err = newAnnotationMatcher(src.AnnotationSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	compiled.fieldSel, err = src.parseFieldSelector()
+	if err != nil {
+		return nil, err
+	}
+
+	compiled.names = newNameMatcher(src.MatchMode, src.Include, src.Exclude)
+
+	compiled.owners, err = newOwnerMatcher(src.Owner, src.NoOwner, src.MatchMode)
+	if err != nil {
+		return nil, err
+	}
+
+	compiled.age, err = newAgeFilter(src.OlderThan, src.NewerThan)
+	if err != nil {
+		return nil, err
+	}
+
+	compiled.requirements, err = compileFieldRequirements(src.FieldRequirements)
+	if err != nil {
+		return nil, err
+	}
+
+	if src.Expression != "" {
+		compiled.expression, err = compileExpression(src.Expression)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return compiled, nil
+}
+
+// inNamespace returns a copy of the CompiledTarget restricted to the given namespace, without its
+// NamespaceSelector. The parsed selectors and patterns are shared with the original.
+func (ct *CompiledTarget) inNamespace(ns string) *CompiledTarget {
+	nsTarget := *ct
+	nsTarget.target.NamespaceSelector = nil
+	nsTarget.target.Namespace = ns
+	nsTarget.namespaceSelector = nil
+
+	return &nsTarget
+}
+
+//+kubebuilder:object:generate=false
+
+// CompiledNamespaceSelector is a NamespaceSelector whose selector and patterns have been parsed
+// ahead of time. Like a CompiledTarget, it is not modified after it is created, so it can be
+// safely shared between goroutines.
+type CompiledNamespaceSelector struct {
+	empty  bool
+	target *CompiledTarget
+}
+
+// Compile parses the selector and patterns in the NamespaceSelector, returning a
+// CompiledNamespaceSelector with the same behavior. An error is returned if the LabelSelector or
+// the AnnotationSelector is malformed. See `Target.Compile` for more details.
+func (sel NamespaceSelector) Compile() (*CompiledNamespaceSelector, error) {
+	t := Target{
+		LabelSelector:      sel.LabelSelector,
+		AnnotationSelector: sel.AnnotationSelector,
+		Include:            sel.Include,
+		Exclude:            sel.Exclude,
+		MatchMode:          sel.MatchMode,
+	}
+
+	compiled, err := t.Compile()
+	if err != nil {
+		return nil, err
+	}
+
+	return &CompiledNamespaceSelector{
+		empty:  len(sel.Include) == 0 && sel.LabelSelector == nil,
+		target: compiled,
+	}, nil
+}
+
+// GetNamespaces fetches all namespaces in the cluster and returns a list of the namespaces that
+// match the compiled NamespaceSelector. See `NamespaceSelector.GetNamespaces` for more details.
+func (cs *CompiledNamespaceSelector) GetNamespaces(
+	ctx context.Context, r client.Reader, opts ...MatchOption,
+) ([]string, error) {
+	namespaces, err := cs.GetNamespaceObjects(ctx, r, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(namespaces))
+	for i, ns := range namespaces {
+		names[i] = ns.GetName()
+	}
+
+	return names, nil
+}
+
+// GetNamespaceObjects fetches all namespaces in the cluster and returns the namespaces that match
+// the compiled NamespaceSelector. See `NamespaceSelector.GetNamespaceObjects` for more details.
+func (cs *CompiledNamespaceSelector) GetNamespaceObjects(
+	ctx context.Context, r client.Reader, opts ...MatchOption,
+) ([]corev1.Namespace, error) {
+	if cs.empty {
+		// A somewhat special case of no matches.
+		return []corev1.Namespace{}, nil
+	}
+
+	matchingNamespaces, err := cs.target.GetMatches(ctx, r, &namespaceResList{}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	excludeTerminating := newMatchOptions(opts).excludeTerminating
+	namespaces := make([]corev1.Namespace, 0, len(matchingNamespaces))
+
+	for _, obj := range matchingNamespaces {
+		ns, ok := obj.(*corev1.Namespace)
+		if !ok {
+			continue
+		}
+
+		if excludeTerminating && namespaceTerminating(ns) {
+			continue
+		}
+
+		namespaces = append(namespaces, *ns)
+	}
+
+	return namespaces, nil
+}
+
+// Matches returns whether the compiled NamespaceSelector matches the given namespace, without any
+// API calls. See `NamespaceSelector.Matches` for more details.
+func (cs *CompiledNamespaceSelector) Matches(ns client.Object, opts ...MatchOption) (bool, error) {
+	explanation, err := cs.ExplainNamespace(ns, opts...)
+
+	return explanation.Included, err
+}
+
+// namespaceTerminating returns whether the namespace is being deleted, based on its deletion
+// timestamp, or its phase when that is available.
+func namespaceTerminating(ns client.Object) bool {
+	if ns.GetDeletionTimestamp() != nil {
+		return true
+	}
+
+	switch typed := ns.(type) {
+	case *corev1.Namespace:
+		return typed.Status.Phase == corev1.NamespaceTerminating
+	case *unstructured.Unstructured:
+		phase, _, _ := unstructured.NestedString(typed.Object, "status", "phase")
+
+		return phase == string(corev1.NamespaceTerminating)
+	default:
+		return false
+	}
+}
+
+// nameMatcher evaluates names against the Include and Exclude patterns of a Target, which have
+// been compiled according to its MatchMode.
+type nameMatcher struct {
+	modeErr error
+	include []compiledPattern
+	exclude []compiledPattern
+}
+
+// compiledPattern is a single Include or Exclude pattern. Regular expressions are compiled ahead
+// of time, and any error from that is kept until the pattern is evaluated. Glob patterns are left
+// to `filepath.Match`, which only reports a malformed pattern when it reaches the bad part.
+type compiledPattern struct {
+	pattern string
+	regex   *regexp.Regexp
+	err     error
+}
+
+func newNameMatcher(mode MatchMode, include, exclude []NonEmptyString) nameMatcher {
+	switch mode {
+	case "", GlobMatchMode, RegexMatchMode:
+	default:
+		return nameMatcher{modeErr: fmt.Errorf("%w: '%s'", ErrUnknownMatchMode, mode)}
+	}
+
+	return nameMatcher{
+		include: compilePatterns(mode, include),
+		exclude: compilePatterns(mode, exclude),
+	}
+}
+
+func compilePatterns(mode MatchMode, patterns []NonEmptyString) []compiledPattern {
+	compiled := make([]compiledPattern, len(patterns))
+
+	for i, pattern := range patterns {
+		compiled[i].pattern = string(pattern)
+
+		if mode == RegexMatchMode {
+			compiled[i].regex, compiled[i].err = compileAnchoredRegex(string(pattern))
+		}
+	}
+
+	return compiled
+}
+
+// match returns whether the given name matches the pattern. Errors from glob patterns will wrap
+// filepath.ErrBadPattern, and errors from regex patterns will wrap a *syntax.Error from the
+// `regexp/syntax` package.
+func (p compiledPattern) match(name string) (bool, error) {
+	if p.err != nil {
+		return false, p.err
+	}
+
+	if p.regex != nil {
+		return p.regex.MatchString(name), nil
+	}
+
+	return filepath.Match(p.pattern, name)
+}
+
+// match returns whether the given name matches the Include and Exclude lists.
+func (m nameMatcher) match(name string) (bool, error) {
+	matched, _, _, err := m.explain(name)
+
+	return matched, err
+}
+
+// explain returns whether the given name matches the Include and Exclude lists, along with the
+// reason and the specific pattern (if any) which decided the result.
+func (m nameMatcher) explain(name string) (bool, MatchReason, string, error) {
+	if m.modeErr != nil {
+		return false, "", "", m.modeErr
+	}
+
+	reason := MatchReasonIncluded // include everything if empty/unset
+	pattern := ""
+
+	if len(m.include) != 0 {
+		reason = MatchReasonNotIncluded
+
+		for _, includePattern := range m.include {
+			include, err := includePattern.match(name)
+			if err != nil {
+				return false, "", "", fmt.Errorf("error parsing 'include' pattern '%s': %w",
+					includePattern.pattern, err)
+			}
+
+			if include {
+				reason = MatchReasonIncluded
+				pattern = includePattern.pattern
+
+				break
+			}
+		}
+
+		if reason == MatchReasonNotIncluded {
+			return false, reason, "", nil
+		}
+	}
+
+	for _, excludePattern := range m.exclude {
+		exclude, err := excludePattern.match(name)
+		if err != nil {
+			return false, "", "", fmt.Errorf("error parsing 'exclude' pattern '%s': %w",
+				excludePattern.pattern, err)
+		}
+
+		if exclude {
+			return false, MatchReasonExcluded, excludePattern.pattern, nil
+		}
+	}
+
+	return true, reason, pattern, nil
+}
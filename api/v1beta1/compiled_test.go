@@ -0,0 +1,471 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var compiledSampleTargets = map[string]Target{
+	"empty": {},
+	"globs": {
+		Include: []NonEmptyString{"b*", "kube-*", "app"},
+		Exclude: []NonEmptyString{"*-t*"},
+	},
+	"regexes": {
+		Include:   []NonEmptyString{"ba.", "kube-(one|two)", "db"},
+		Exclude:   []NonEmptyString{"baz"},
+		MatchMode: RegexMatchMode,
+	},
+	"label and field selectors": {
+		LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key:      "sample",
+			Operator: metav1.LabelSelectorOpNotIn,
+			Values:   []string{"foo"},
+		}}},
+		FieldSelector: "metadata.name!=bar",
+	},
+	"namespace selector": {
+		NamespaceSelector: &NamespaceSelector{
+			Include:   []NonEmptyString{"team-[ab]"},
+			MatchMode: RegexMatchMode,
+		},
+		Exclude: []NonEmptyString{"db"},
+	},
+}
+
+func TestCompiledTargetGetMatches(t *testing.T) {
+	t.Parallel()
+
+	objs := append(sampleConfigMaps(), sampleNamespacedObjects()...)
+
+	for name, target := range compiledSampleTargets {
+		fakeClient := fake.NewClientBuilder().WithRuntimeObjects(objs...).Build()
+
+		compiled, err := target.Compile()
+		if err != nil {
+			t.Fatalf("Unexpected error '%v' compiling test '%v'", err, name)
+		}
+
+		want, err := target.GetMatches(context.TODO(), fakeClient, &configMapResList{})
+		if err != nil {
+			t.Errorf("Unexpected error '%v' from the Target, in test '%v'", err, name)
+		}
+
+		got, err := compiled.GetMatches(context.TODO(), fakeClient, &configMapResList{})
+		if err != nil {
+			t.Errorf("Unexpected error '%v' from the CompiledTarget, in test '%v'", err, name)
+		}
+
+		if diff := cmp.Diff(objNamespacedNames(want), objNamespacedNames(got)); diff != "" {
+			t.Errorf("Mismatch in test '%v': %v", name, diff)
+		}
+
+		dynClient := dynfake.NewSimpleDynamicClient(scheme.Scheme, objs...)
+		cmIface := dynClient.Resource(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"})
+
+		wantDyn, err := target.GetMatchesDynamic(context.TODO(), cmIface, WithNamespaceReader(fakeClient))
+		if err != nil {
+			t.Errorf("Unexpected error '%v' from the Target dynamically, in test '%v'", err, name)
+		}
+
+		gotDyn, err := compiled.GetMatchesDynamic(context.TODO(), cmIface, WithNamespaceReader(fakeClient))
+		if err != nil {
+			t.Errorf("Unexpected error '%v' from the CompiledTarget dynamically, in test '%v'", err, name)
+		}
+
+		if diff := cmp.Diff(objNamespacedNames(wantDyn), objNamespacedNames(gotDyn)); diff != "" {
+			t.Errorf("Mismatch from GetMatchesDynamic in test '%v': %v", name, diff)
+		}
+	}
+}
+
+func TestCompiledNamespaceSelectorGetNamespaces(t *testing.T) {
+	t.Parallel()
+
+	fakeClient := fake.NewClientBuilder().WithRuntimeObjects(sampleNamespacedObjects()...).Build()
+
+	for name, target := range compiledSampleTargets {
+		sel := NamespaceSelector{
+			LabelSelector: target.LabelSelector,
+			Include:       target.Include,
+			Exclude:       target.Exclude,
+			MatchMode:     target.MatchMode,
+		}
+
+		compiled, err := sel.Compile()
+		if err != nil {
+			t.Fatalf("Unexpected error '%v' compiling test '%v'", err, name)
+		}
+
+		want, err := sel.GetNamespaces(context.TODO(), fakeClient)
+		if err != nil {
+			t.Errorf("Unexpected error '%v' from the NamespaceSelector, in test '%v'", err, name)
+		}
+
+		got, err := compiled.GetNamespaces(context.TODO(), fakeClient)
+		if err != nil {
+			t.Errorf("Unexpected error '%v' from the CompiledNamespaceSelector, in test '%v'", err, name)
+		}
+
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Mismatch in test '%v': %v", name, diff)
+		}
+	}
+}
+
+func TestTargetMatchesAgreesWithGetMatches(t *testing.T) {
+	t.Parallel()
+
+	objs := append(sampleConfigMaps(), sampleNamespacedObjects()...)
+	fakeClient := fake.NewClientBuilder().WithRuntimeObjects(objs...).Build()
+
+	namespaces := map[string]client.Object{}
+	configMaps := make([]client.Object, 0)
+
+	for _, obj := range objs {
+		switch typed := obj.(type) {
+		case *corev1.Namespace:
+			namespaces[typed.Name] = typed
+		case *corev1.ConfigMap:
+			configMaps = append(configMaps, typed)
+		}
+	}
+
+	for name, target := range compiledSampleTargets {
+		matches, err := target.GetMatches(context.TODO(), fakeClient, &configMapResList{})
+		if err != nil {
+			t.Fatalf("Unexpected error '%v' from GetMatches, in test '%v'", err, name)
+		}
+
+		want := objNamespacedNames(matches)
+		got := make([]string, 0)
+
+		for _, cm := range configMaps {
+			matched, err := target.Matches(cm, namespaces[cm.GetNamespace()])
+			if err != nil {
+				t.Errorf("Unexpected error '%v' from Matches, in test '%v'", err, name)
+			}
+
+			if matched {
+				got = append(got, cm.GetNamespace()+"/"+cm.GetName())
+			}
+		}
+
+		less := func(a, b string) bool { return a < b }
+		if diff := cmp.Diff(want, got, cmpopts.SortSlices(less)); diff != "" {
+			t.Errorf("Mismatch in test '%v': %v", name, diff)
+		}
+	}
+
+	selTarget := compiledSampleTargets["namespace selector"]
+
+	matched, err := selTarget.Matches(configMaps[0], nil)
+	if err != nil || matched {
+		t.Errorf("Expected no match without a namespace, got %v, '%v'", matched, err)
+	}
+}
+
+func TestNamespaceSelectorMatchesAgreesWithGetNamespaces(t *testing.T) {
+	t.Parallel()
+
+	fakeClient := fake.NewClientBuilder().WithRuntimeObjects(sampleNamespacedObjects()...).Build()
+
+	namespaces := make([]client.Object, 0)
+
+	for _, obj := range sampleNamespacedObjects() {
+		if ns, ok := obj.(*corev1.Namespace); ok {
+			namespaces = append(namespaces, ns)
+		}
+	}
+
+	for name, target := range compiledSampleTargets {
+		sel := NamespaceSelector{
+			LabelSelector: target.LabelSelector,
+			Include:       target.Include,
+			Exclude:       target.Exclude,
+			MatchMode:     target.MatchMode,
+		}
+
+		want, err := sel.GetNamespaces(context.TODO(), fakeClient)
+		if err != nil {
+			t.Fatalf("Unexpected error '%v' from GetNamespaces, in test '%v'", err, name)
+		}
+
+		got := make([]string, 0)
+
+		for _, ns := range namespaces {
+			matched, err := sel.Matches(ns)
+			if err != nil {
+				t.Errorf("Unexpected error '%v' from Matches, in test '%v'", err, name)
+			}
+
+			if matched {
+				got = append(got, ns.GetName())
+			}
+		}
+
+		less := func(a, b string) bool { return a < b }
+		if diff := cmp.Diff(want, got, cmpopts.SortSlices(less)); diff != "" {
+			t.Errorf("Mismatch in test '%v': %v", name, diff)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	t.Parallel()
+
+	badSelector := &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+		Key:      "sample",
+		Operator: metav1.LabelSelectorOpExists,
+		Values:   []string{"foo"},
+	}}}
+
+	if _, err := (Target{LabelSelector: badSelector}).Compile(); err == nil {
+		t.Error("Expected an error compiling a malformed LabelSelector")
+	}
+
+	if _, err := (Target{FieldSelector: "data.tier"}).Compile(); err == nil {
+		t.Error("Expected an error compiling a malformed FieldSelector")
+	}
+
+	if _, err := (Target{NamespaceSelector: &NamespaceSelector{LabelSelector: badSelector}}).Compile(); err == nil {
+		t.Error("Expected an error compiling a malformed LabelSelector in the NamespaceSelector")
+	}
+
+	// Malformed patterns are only reported when they are evaluated, like with the Target.
+	compiled, err := Target{Include: []NonEmptyString{"kube-(system"}, MatchMode: RegexMatchMode}.Compile()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v' compiling a malformed pattern", err)
+	}
+
+	fakeClient := fake.NewClientBuilder().WithRuntimeObjects(sampleConfigMaps()...).Build()
+
+	_, err = compiled.GetMatches(context.TODO(), fakeClient, &configMapResList{})
+
+	want := "error parsing 'include' pattern 'kube-(system': " +
+		"error parsing regexp: missing closing ): `kube-(system`"
+	if err == nil || err.Error() != want {
+		t.Errorf("Expected error '%v', got '%v'", want, err)
+	}
+}
+
+func TestCompiledTargetIsIndependent(t *testing.T) {
+	t.Parallel()
+
+	withClock := WithClock(clocktesting.NewFakePassiveClock(sampleNow))
+
+	tests := map[string]struct {
+		target Target
+		modify func(*Target)
+		want   []string
+	}{
+		"include and namespace": {
+			target: Target{Include: []NonEmptyString{"b*"}},
+			modify: func(target *Target) {
+				target.Include[0] = "f*"
+				target.Namespace = "kube-system"
+			},
+			want: []string{"bar", "baz", "boo"},
+		},
+		"age filters": {
+			target: Target{
+				OlderThan: &metav1.Duration{Duration: 5 * time.Minute},
+				NewerThan: &metav1.Duration{Duration: 90 * 24 * time.Hour},
+			},
+			modify: func(target *Target) {
+				target.OlderThan.Duration = 0
+				target.NewerThan.Duration = 200 * 24 * time.Hour
+			},
+			want: []string{"bar", "baz"},
+		},
+		"owner selector": {
+			target: Target{Owner: &OwnerSelector{Kind: "ReplicaSet", Name: "web-*"}},
+			modify: func(target *Target) {
+				target.Owner.Kind = "Secret"
+				target.Owner.Name = "*"
+			},
+			want: []string{"bar", "foo"},
+		},
+		"annotation selector": {
+			target: Target{AnnotationSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key:      "owner",
+					Operator: metav1.LabelSelectorOpIn,
+					Values:   []string{"https://example.com/teams/foo"},
+				}},
+			}},
+			modify: func(target *Target) {
+				target.AnnotationSelector.MatchExpressions[0].Values[0] = "https://example.com/teams/bar"
+			},
+			want: []string{"foo"},
+		},
+	}
+
+	for name, tcase := range tests {
+		compiled, err := tcase.target.Compile()
+		if err != nil {
+			t.Fatalf("Unexpected error '%v', in test '%v'", err, name)
+		}
+
+		tcase.modify(&tcase.target)
+
+		fakeClient := fake.NewClientBuilder().WithRuntimeObjects(detailedConfigMaps()...).Build()
+
+		got, err := compiled.GetMatches(context.TODO(), fakeClient, &configMapResList{}, withClock)
+		if err != nil {
+			t.Fatalf("Unexpected error '%v', in test '%v'", err, name)
+		}
+
+		if diff := cmp.Diff(tcase.want, objNames(got)); diff != "" {
+			t.Errorf("Mismatch after modifying the original Target in test '%v': %v", name, diff)
+		}
+	}
+}
+
+func TestCompiledTargetConcurrentUse(t *testing.T) {
+	t.Parallel()
+
+	compiled, err := compiledSampleTargets["namespace selector"].Compile()
+	if err != nil {
+		t.Fatalf("Unexpected error '%v'", err)
+	}
+
+	fakeClient := fake.NewClientBuilder().WithRuntimeObjects(sampleNamespacedObjects()...).Build()
+	want := []string{"team-a/app", "team-b/app"}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			got, err := compiled.GetMatches(context.TODO(), fakeClient, &configMapResList{})
+			if err != nil {
+				t.Errorf("Unexpected error '%v'", err)
+
+				return
+			}
+
+			if diff := cmp.Diff(want, objNamespacedNames(got)); diff != "" {
+				t.Errorf("Mismatch: %v", diff)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// staticConfigMapReader returns the same ConfigMaps for every List call, ignoring the options, so
+// that the benchmarks measure the matching rather than a fake client.
+type staticConfigMapReader struct {
+	client.Reader
+	items []corev1.ConfigMap
+}
+
+func (r staticConfigMapReader) List(_ context.Context, list client.ObjectList, _ ...client.ListOption) error {
+	cmList, ok := list.(*corev1.ConfigMapList)
+	if !ok {
+		return fmt.Errorf("unexpected list type %T", list)
+	}
+
+	cmList.Items = r.items
+
+	return nil
+}
+
+func benchmarkReader(count int) staticConfigMapReader {
+	items := make([]corev1.ConfigMap, count)
+	for i := range items {
+		items[i].Name = fmt.Sprintf("app-%d-config", i)
+		items[i].Namespace = "default"
+	}
+
+	return staticConfigMapReader{items: items}
+}
+
+var benchmarkTargets = map[string]Target{
+	"Glob": {
+		Include: []NonEmptyString{"app-1*", "app-2*", "app-*-config"},
+		Exclude: []NonEmptyString{"app-99*", "*-secret"},
+	},
+	"Regex": {
+		Include:   []NonEmptyString{"app-1[0-9]*-config", "app-2.*", "app-[0-9]+-config"},
+		Exclude:   []NonEmptyString{"app-99.*", ".*-secret"},
+		MatchMode: RegexMatchMode,
+	},
+}
+
+func BenchmarkGetMatches(b *testing.B) {
+	reader := benchmarkReader(1000)
+
+	for name, target := range benchmarkTargets {
+		b.Run(name+"/Target", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := target.GetMatches(context.TODO(), reader, &configMapResList{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(name+"/CompiledTarget", func(b *testing.B) {
+			compiled, err := target.Compile()
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := compiled.GetMatches(context.TODO(), reader, &configMapResList{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkMatchName(b *testing.B) {
+	for name, target := range benchmarkTargets {
+		b.Run(name+"/Target", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				matcher := newNameMatcher(target.MatchMode, target.Include, target.Exclude)
+				if _, err := matcher.match("app-123-config"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(name+"/CompiledTarget", func(b *testing.B) {
+			compiled, err := target.Compile()
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := compiled.names.match("app-123-config"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -3,11 +3,32 @@
 package v1beta1
 
 import (
+	"slices"
 	"sort"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// ConditionTypeCompliant is the type of the condition which details the policy's compliance. Its
+	// status should be consistent with the ComplianceState; use `SetCompliance` to set them together.
+	ConditionTypeCompliant = "Compliant"
+
+	// ReasonCompliant is the default reason on the Compliant condition when the policy is compliant.
+	ReasonCompliant = "Compliant"
+
+	// ReasonNonCompliant is the default reason on the Compliant condition when the policy is not
+	// compliant.
+	ReasonNonCompliant = "NonCompliant"
+
+	// ReasonUnknownCompliancy is the default reason on the Compliant condition when the compliance
+	// could not be determined.
+	ReasonUnknownCompliancy = "UnknownCompliancy"
+)
+
+// MaxComplianceHistory is the maximum number of entries kept in the ComplianceHistory.
+const MaxComplianceHistory = 10
+
 // GetCondition returns the existing index and condition on the status matching the given type. If
 // no condition of that type is found, it will return -1 as the index.
 func (status PolicyCoreStatus) GetCondition(condType string) (int, metav1.Condition) {
@@ -21,7 +42,9 @@ func (status PolicyCoreStatus) GetCondition(condType string) (int, metav1.Condit
 }
 
 // UpdateCondition modifies the specified condition in the status or adds it if not present,
-// ensuring conditions remain sorted by Type. Returns true if the condition was updated or added.
+// ensuring conditions remain sorted by Type. Returns true if the condition was updated or added,
+// including when only its ObservedGeneration changed. In that case, the existing
+// LastTransitionTime is kept, since the condition did not transition.
 func (status *PolicyCoreStatus) UpdateCondition(newCond metav1.Condition) (changed bool) {
 	idx, existingCond := status.GetCondition(newCond.Type)
 	if idx == -1 {
@@ -45,14 +68,155 @@ func (status *PolicyCoreStatus) UpdateCondition(newCond metav1.Condition) (chang
 
 		// Do not sort in this case, assume that they are in order.
 
+		return true
+	} else if newCond.ObservedGeneration != existingCond.ObservedGeneration {
+		status.Conditions[idx].ObservedGeneration = newCond.ObservedGeneration
+
 		return true
 	}
 
 	return false
 }
 
+// RemoveCondition removes the condition of the given type from the status, if it is present. The
+// other conditions remain sorted by Type. Returns true if a condition was removed.
+func (status *PolicyCoreStatus) RemoveCondition(condType string) (changed bool) {
+	return status.removeConditions(func(cond metav1.Condition) bool {
+		return cond.Type == condType
+	})
+}
+
+// PruneConditions removes every condition whose type is not in the allowed list, for example the
+// conditions for features which are no longer used in the policy's spec. The remaining conditions
+// stay sorted by Type. Returns true if any conditions were removed.
+func (status *PolicyCoreStatus) PruneConditions(allowedTypes ...string) (changed bool) {
+	return status.removeConditions(func(cond metav1.Condition) bool {
+		return !slices.Contains(allowedTypes, cond.Type)
+	})
+}
+
+// removeConditions removes the conditions matching the function, without reordering the others.
+func (status *PolicyCoreStatus) removeConditions(remove func(metav1.Condition) bool) (changed bool) {
+	prevLen := len(status.Conditions)
+
+	status.Conditions = slices.DeleteFunc(status.Conditions, remove)
+
+	return len(status.Conditions) != prevLen
+}
+
 func condSemanticallyChanged(newCond, oldCond metav1.Condition) bool {
 	return newCond.Message != oldCond.Message ||
 		newCond.Reason != oldCond.Reason ||
 		newCond.Status != oldCond.Status
 }
+
+// IsStale returns whether the status was last evaluated for a different generation of the policy
+// than the given one, which should usually be the policy's `metadata.generation`. Controllers
+// should set the ObservedGeneration when they evaluate the policy, so that this is false until the
+// spec changes again.
+func (status PolicyCoreStatus) IsStale(generation int64) bool {
+	return status.ObservedGeneration != generation
+}
+
+// SetObservedGeneration records the generation of the policy being evaluated, which should usually
+// be the policy's `metadata.generation`. Returns true if it was different from the previous one, in
+// which case the status should be updated even if the compliance did not change.
+func (status *PolicyCoreStatus) SetObservedGeneration(generation int64) (changed bool) {
+	changed = status.IsStale(generation)
+	status.ObservedGeneration = generation
+
+	return changed
+}
+
+// SetCompliance sets the ComplianceState and the Compliant condition together, so that they are
+// consistent. The condition's status is True when the state is Compliant, False when it is
+// NonCompliant, and Unknown otherwise. If the reason is empty, a default reason for the state is
+// used. The condition's ObservedGeneration is copied from the status, so that should be set first,
+// with `SetObservedGeneration`. Returns true when the state, reason, or message changes, for
+// example to decide whether the status should be updated: a new ObservedGeneration on its own is
+// recorded in the condition, but is not reported here, since it is already reported by
+// `SetObservedGeneration`. Only transitions are recorded in the ComplianceHistory: when the state
+// or reason changes, an entry is added to the front, and the oldest entries beyond
+// MaxComplianceHistory are dropped. A message which changes on its own (for example, one listing
+// counts or names) does not add an entry, so it can not push the transitions out of the history.
+func (status *PolicyCoreStatus) SetCompliance(state ComplianceState, reason, message string) (changed bool) {
+	cond := metav1.Condition{
+		Type:               ConditionTypeCompliant,
+		Status:             metav1.ConditionUnknown,
+		ObservedGeneration: status.ObservedGeneration,
+		Reason:             ReasonUnknownCompliancy,
+		Message:            message,
+	}
+
+	switch state {
+	case Compliant:
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = ReasonCompliant
+	case NonCompliant:
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = ReasonNonCompliant
+	case UnknownCompliancy:
+	}
+
+	if reason != "" {
+		cond.Reason = reason
+	}
+
+	idx, existingCond := status.GetCondition(ConditionTypeCompliant)
+	condChanged := idx == -1 || condSemanticallyChanged(cond, existingCond)
+	transitioned := idx == -1 || existingCond.Reason != cond.Reason || status.ComplianceState != state
+	changed = condChanged || status.ComplianceState != state
+
+	status.ComplianceState = state
+	status.UpdateCondition(cond)
+
+	if !transitioned {
+		return changed
+	}
+
+	// When only the ComplianceState changed, the condition keeps its old LastTransitionTime, which
+	// is not when this transition happened.
+	timestamp := metav1.Now()
+
+	if condChanged {
+		_, newCond := status.GetCondition(ConditionTypeCompliant)
+		timestamp = newCond.LastTransitionTime
+	}
+
+	status.addComplianceHistory(ComplianceHistoryEntry{
+		Timestamp:       timestamp,
+		ComplianceState: state,
+		Message:         message,
+	})
+
+	return true
+}
+
+// addComplianceHistory adds the entry to the front of the ComplianceHistory, dropping the oldest
+// entries beyond MaxComplianceHistory.
+func (status *PolicyCoreStatus) addComplianceHistory(entry ComplianceHistoryEntry) {
+	history := make([]ComplianceHistoryEntry, 0, MaxComplianceHistory)
+	history = append(history, entry)
+
+	for _, prev := range status.ComplianceHistory {
+		if len(history) == MaxComplianceHistory {
+			break
+		}
+
+		history = append(history, prev)
+	}
+
+	status.ComplianceHistory = history
+}
+
+// SetCompliant marks the policy as Compliant, with the given reason and message on the Compliant
+// condition. See `SetCompliance` for more details.
+func (status *PolicyCoreStatus) SetCompliant(reason, message string) (changed bool) {
+	return status.SetCompliance(Compliant, reason, message)
+}
+
+// SetNonCompliant marks the policy as NonCompliant, with the given reason and message on the
+// Compliant condition. See `SetCompliance` for more details.
+func (status *PolicyCoreStatus) SetNonCompliant(reason, message string) (changed bool) {
+	return status.SetCompliance(NonCompliant, reason, message)
+}
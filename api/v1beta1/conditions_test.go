@@ -3,8 +3,11 @@
 package v1beta1
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -126,6 +129,18 @@ func TestUpdateCondition(t *testing.T) {
 			wantLen:    baseLen,
 			wantIdx:    0,
 		},
+		"Apple should be updated if only the generation is different": {
+			newCond: metav1.Condition{
+				Type:               "Apple",
+				Status:             metav1.ConditionTrue,
+				ObservedGeneration: 2,
+				Reason:             "NoDoctor",
+				Message:            "an apple a day...",
+			},
+			wantChange: true,
+			wantLen:    baseLen,
+			wantIdx:    0,
+		},
 		"Apple should not be updated if the message is the same": {
 			newCond: metav1.Condition{
 				Type:    "Apple",
@@ -167,5 +182,321 @@ func TestUpdateCondition(t *testing.T) {
 			t.Errorf("Expected condition to have message %q after test %q, got %q",
 				tcase.newCond.Message, name, gotCond.Message)
 		}
+
+		if gotCond.ObservedGeneration != tcase.newCond.ObservedGeneration {
+			t.Errorf("Expected condition to have observedGeneration %v after test %q, got %v",
+				tcase.newCond.ObservedGeneration, name, gotCond.ObservedGeneration)
+		}
+	}
+}
+
+func TestSetCompliance(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		set         func(status *PolicyCoreStatus) bool
+		wantChanged bool
+		wantState   ComplianceState
+		wantStatus  metav1.ConditionStatus
+		wantReason  string
+		wantMsg     string
+	}{
+		"the same compliance is not a change": {
+			set: func(status *PolicyCoreStatus) bool {
+				return status.SetCompliant("", "everything is good")
+			},
+			wantChanged: false,
+			wantState:   Compliant,
+			wantStatus:  metav1.ConditionTrue,
+			wantReason:  ReasonCompliant,
+			wantMsg:     "everything is good",
+		},
+		"a new message is a change": {
+			set: func(status *PolicyCoreStatus) bool {
+				return status.SetCompliant("", "everything is great")
+			},
+			wantChanged: true,
+			wantState:   Compliant,
+			wantStatus:  metav1.ConditionTrue,
+			wantReason:  ReasonCompliant,
+			wantMsg:     "everything is great",
+		},
+		"becoming NonCompliant with a custom reason": {
+			set: func(status *PolicyCoreStatus) bool {
+				return status.SetNonCompliant("Missing", "something is missing")
+			},
+			wantChanged: true,
+			wantState:   NonCompliant,
+			wantStatus:  metav1.ConditionFalse,
+			wantReason:  "Missing",
+			wantMsg:     "something is missing",
+		},
+		"becoming UnknownCompliancy": {
+			set: func(status *PolicyCoreStatus) bool {
+				return status.SetCompliance(UnknownCompliancy, "", "the cluster could not be reached")
+			},
+			wantChanged: true,
+			wantState:   UnknownCompliancy,
+			wantStatus:  metav1.ConditionUnknown,
+			wantReason:  ReasonUnknownCompliancy,
+			wantMsg:     "the cluster could not be reached",
+		},
+	}
+
+	for name, tcase := range tests {
+		status := getSampleStatus()
+		status.ComplianceState = Compliant
+
+		if changed := tcase.set(&status); changed != tcase.wantChanged {
+			t.Errorf("Expected changed to be %v in test '%v'", tcase.wantChanged, name)
+		}
+
+		if status.ComplianceState != tcase.wantState {
+			t.Errorf("Expected state %v in test '%v', got %v", tcase.wantState, name, status.ComplianceState)
+		}
+
+		_, cond := status.GetCondition(ConditionTypeCompliant)
+
+		if cond.Status != tcase.wantStatus {
+			t.Errorf("Expected condition status %v in test '%v', got %v", tcase.wantStatus, name, cond.Status)
+		}
+
+		if cond.Reason != tcase.wantReason {
+			t.Errorf("Expected condition reason %v in test '%v', got %v", tcase.wantReason, name, cond.Reason)
+		}
+
+		if cond.Message != tcase.wantMsg {
+			t.Errorf("Expected condition message %v in test '%v', got %v", tcase.wantMsg, name, cond.Message)
+		}
+	}
+}
+
+func TestSetComplianceStateOnly(t *testing.T) {
+	t.Parallel()
+
+	status := getSampleStatus()
+	transitioned := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	idx, _ := status.GetCondition(ConditionTypeCompliant)
+	status.Conditions[idx].LastTransitionTime = transitioned
+
+	// The condition already matches, but the ComplianceState was never set.
+	if !status.SetCompliant("", "everything is good") {
+		t.Errorf("Expected a change when only the ComplianceState was different")
+	}
+
+	if status.ComplianceState != Compliant {
+		t.Errorf("Expected the ComplianceState to be set, got %v", status.ComplianceState)
+	}
+
+	if !status.Conditions[idx].LastTransitionTime.Equal(&transitioned) {
+		t.Errorf("Expected the condition's LastTransitionTime to be kept, got %v",
+			status.Conditions[idx].LastTransitionTime)
+	}
+
+	if len(status.ComplianceHistory) != 1 || !status.ComplianceHistory[0].Timestamp.After(transitioned.Time) {
+		t.Errorf("Expected a history entry with the time of this change, got %+v", status.ComplianceHistory)
+	}
+}
+
+func TestUpdateConditionGenerationKeepsTransitionTime(t *testing.T) {
+	t.Parallel()
+
+	status := getSampleStatus()
+	transitioned := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	status.Conditions[0].LastTransitionTime = transitioned
+
+	newCond := status.Conditions[0]
+	newCond.ObservedGeneration = 3
+	newCond.LastTransitionTime = metav1.Time{}
+
+	if !status.UpdateCondition(newCond) {
+		t.Fatalf("Expected a change when only the generation was different")
+	}
+
+	if !status.Conditions[0].LastTransitionTime.Equal(&transitioned) {
+		t.Errorf("Expected the LastTransitionTime to be kept, got %v", status.Conditions[0].LastTransitionTime)
+	}
+}
+
+func TestIsStale(t *testing.T) {
+	t.Parallel()
+
+	status := PolicyCoreStatus{}
+
+	if !status.IsStale(1) {
+		t.Errorf("Expected a status which was never evaluated to be stale")
+	}
+
+	status.ObservedGeneration = 1
+
+	if status.IsStale(1) {
+		t.Errorf("Expected the status to not be stale for the observed generation")
+	}
+
+	if !status.IsStale(2) {
+		t.Errorf("Expected the status to be stale for a newer generation")
+	}
+
+	if !status.SetObservedGeneration(2) {
+		t.Errorf("Expected setting a new observed generation to be a change")
+	}
+
+	if status.SetObservedGeneration(2) {
+		t.Errorf("Expected setting the same observed generation to not be a change")
+	}
+
+	if !status.SetCompliant("", "everything is good") {
+		t.Errorf("Expected setting the compliance to be a change")
+	}
+
+	if _, cond := status.GetCondition(ConditionTypeCompliant); cond.ObservedGeneration != 2 {
+		t.Errorf("Expected the Compliant condition to have the observed generation, got %v",
+			cond.ObservedGeneration)
 	}
+
+	status.SetObservedGeneration(3)
+
+	if status.SetCompliant("", "everything is good") {
+		t.Errorf("Expected the same compliance with only a new generation to not be a change")
+	}
+
+	if _, cond := status.GetCondition(ConditionTypeCompliant); cond.ObservedGeneration != 3 {
+		t.Errorf("Expected the Compliant condition to have the new observed generation, got %v",
+			cond.ObservedGeneration)
+	}
+}
+
+func TestComplianceHistory(t *testing.T) {
+	t.Parallel()
+
+	status := PolicyCoreStatus{}
+
+	status.SetNonCompliant("", "msg-0")
+
+	// Repeating the same compliance should not add an entry, even with a new generation.
+	status.ObservedGeneration = 2
+	status.SetNonCompliant("", "msg-0")
+
+	if len(status.ComplianceHistory) != 1 {
+		t.Fatalf("Expected one history entry, got %v", len(status.ComplianceHistory))
+	}
+
+	// A new message on its own is a change, but not a transition.
+	if !status.SetNonCompliant("", "msg-0, with more details") {
+		t.Errorf("Expected a new message to be a change")
+	}
+
+	if len(status.ComplianceHistory) != 1 {
+		t.Fatalf("Expected a new message to not add a history entry, got %v", len(status.ComplianceHistory))
+	}
+
+	// A new reason is a transition, even with the same state.
+	status.SetNonCompliant("NotFound", "msg-0, with more details")
+
+	if len(status.ComplianceHistory) != 2 || status.ComplianceHistory[0].Message != "msg-0, with more details" {
+		t.Fatalf("Expected a new reason to add a history entry, got %+v", status.ComplianceHistory)
+	}
+
+	for i := 1; i <= MaxComplianceHistory+2; i++ {
+		status.SetCompliance([]ComplianceState{Compliant, NonCompliant}[i%2], "", fmt.Sprintf("msg-%v", i))
+	}
+
+	if len(status.ComplianceHistory) != MaxComplianceHistory {
+		t.Fatalf("Expected the history to be capped at %v, got %v", MaxComplianceHistory,
+			len(status.ComplianceHistory))
+	}
+
+	newest := status.ComplianceHistory[0]
+	if newest.Message != fmt.Sprintf("msg-%v", MaxComplianceHistory+2) || newest.ComplianceState != Compliant {
+		t.Errorf("Unexpected newest history entry: %+v", newest)
+	}
+
+	if newest.Timestamp.IsZero() {
+		t.Errorf("Expected the newest history entry to have a timestamp")
+	}
+
+	oldest := status.ComplianceHistory[MaxComplianceHistory-1]
+	if oldest.Message != "msg-3" {
+		t.Errorf("Expected the oldest entries to be dropped, but the oldest entry is: %+v", oldest)
+	}
+}
+
+func TestRemoveCondition(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		condType   string
+		wantChange bool
+		wantTypes  []string
+	}{
+		"Compliant is removed": {
+			condType:   "Compliant",
+			wantChange: true,
+			wantTypes:  []string{"Apple", "Bonus"},
+		},
+		"Imaginary is not found": {
+			condType:   "Imaginary",
+			wantChange: false,
+			wantTypes:  []string{"Apple", "Compliant", "Bonus"},
+		},
+	}
+
+	for name, tcase := range tests {
+		status := getSampleStatus()
+
+		if gotChanged := status.RemoveCondition(tcase.condType); gotChanged != tcase.wantChange {
+			t.Errorf("Expected changed to be %v in test %q, got %v", tcase.wantChange, name, gotChanged)
+		}
+
+		if diff := cmp.Diff(tcase.wantTypes, conditionTypes(status)); diff != "" {
+			t.Errorf("Mismatch in the remaining conditions in test %q: %v", name, diff)
+		}
+	}
+}
+
+func TestPruneConditions(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		allowed    []string
+		wantChange bool
+		wantTypes  []string
+	}{
+		"everything is allowed": {
+			allowed:    []string{"Apple", "Bonus", "Compliant", "Imaginary"},
+			wantChange: false,
+			wantTypes:  []string{"Apple", "Compliant", "Bonus"},
+		},
+		"some are pruned": {
+			allowed:    []string{"Bonus", "Apple"},
+			wantChange: true,
+			wantTypes:  []string{"Apple", "Bonus"},
+		},
+		"nothing is allowed": {
+			allowed:    nil,
+			wantChange: true,
+			wantTypes:  []string{},
+		},
+	}
+
+	for name, tcase := range tests {
+		status := getSampleStatus()
+
+		if gotChanged := status.PruneConditions(tcase.allowed...); gotChanged != tcase.wantChange {
+			t.Errorf("Expected changed to be %v in test %q, got %v", tcase.wantChange, name, gotChanged)
+		}
+
+		if diff := cmp.Diff(tcase.wantTypes, conditionTypes(status)); diff != "" {
+			t.Errorf("Mismatch in the remaining conditions in test %q: %v", name, diff)
+		}
+	}
+}
+
+func conditionTypes(status PolicyCoreStatus) []string {
+	types := make([]string, 0, len(status.Conditions))
+	for _, cond := range status.Conditions {
+		types = append(types, cond.Type)
+	}
+
+	return types
 }
@@ -0,0 +1,421 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// MatchReason describes which rule decided whether an object was matched.
+type MatchReason string
+
+const (
+	// MatchReasonIncluded indicates that the object was matched. If the Target has Include
+	// patterns, the pattern which matched the object is given in the explanation.
+	MatchReasonIncluded MatchReason = "Included"
+
+	// MatchReasonNotIncluded indicates that the object's name did not match any of the Include
+	// patterns.
+	MatchReasonNotIncluded MatchReason = "NotIncluded"
+
+	// MatchReasonExcluded indicates that the object's name matched one of the Exclude patterns,
+	// which is given in the explanation.
+	MatchReasonExcluded MatchReason = "Excluded"
+
+	// MatchReasonLabelSelector indicates that the object's labels did not match the LabelSelector.
+	MatchReasonLabelSelector MatchReason = "LabelSelector"
+
+	// MatchReasonAnnotationSelector indicates that the object's annotations did not match the
+	// AnnotationSelector.
+	MatchReasonAnnotationSelector MatchReason = "AnnotationSelector"
+
+	// MatchReasonFieldSelector indicates that the object's fields did not match the FieldSelector.
+	MatchReasonFieldSelector MatchReason = "FieldSelector"
+
+	// MatchReasonNamespace indicates that the object was not in the Target's Namespace, or in one
+	// of the namespaces selected by its NamespaceSelector.
+	MatchReasonNamespace MatchReason = "Namespace"
+
+	// MatchReasonOwner indicates that the object's name was matched, but its owner references did
+	// not match the Owner or NoOwner filter.
+	MatchReasonOwner MatchReason = "Owner"
+
+	// MatchReasonAge indicates that the object's name was matched, but its creation timestamp was
+	// not within the OlderThan and NewerThan filters.
+	MatchReasonAge MatchReason = "Age"
+
+	// MatchReasonFieldRequirement indicates that the object's name was matched, but one of the
+	// FieldRequirements was not satisfied. The requirement is given in the explanation.
+	MatchReasonFieldRequirement MatchReason = "FieldRequirement"
+
+	// MatchReasonExpression indicates that the object's name was matched, but the Expression
+	// evaluated to false for it.
+	MatchReasonExpression MatchReason = "Expression"
+
+	// MatchReasonEmptySelector indicates that the NamespaceSelector was empty, and so did not
+	// match any namespaces.
+	MatchReasonEmptySelector MatchReason = "EmptySelector"
+
+	// MatchReasonTerminating indicates that the namespace would have been matched, but it is being
+	// deleted, and terminating namespaces were excluded with the ExcludeTerminatingNamespaces option.
+	MatchReasonTerminating MatchReason = "Terminating"
+)
+
+//+kubebuilder:object:generate=false
+
+// MatchExplanation describes whether a specific object was matched, and why.
+type MatchExplanation struct {
+	// Object is the object that was evaluated.
+	Object client.Object
+
+	// Included is whether the object was matched.
+	Included bool
+
+	// Reason is the rule which decided whether the object was matched.
+	Reason MatchReason
+
+	// Pattern is the Include or Exclude pattern which decided whether the object was matched, if
+	// the Reason was based on one of those lists, or the FieldRequirement which was not satisfied.
+	Pattern string
+}
+
+// String returns a human-readable description of the explanation, suitable for logs or condition
+// messages.
+func (e MatchExplanation) String() string {
+	name := e.Object.GetName()
+	if ns := e.Object.GetNamespace(); ns != "" {
+		name = ns + "/" + name
+	}
+
+	switch e.Reason {
+	case MatchReasonIncluded:
+		if e.Pattern == "" {
+			return fmt.Sprintf("%s: included, since there are no include patterns", name)
+		}
+
+		return fmt.Sprintf("%s: included by the include pattern '%s'", name, e.Pattern)
+	case MatchReasonNotIncluded:
+		return fmt.Sprintf("%s: not matched by any include pattern", name)
+	case MatchReasonExcluded:
+		return fmt.Sprintf("%s: excluded by the exclude pattern '%s'", name, e.Pattern)
+	case MatchReasonLabelSelector:
+		return fmt.Sprintf("%s: not matched by the label selector", name)
+	case MatchReasonAnnotationSelector:
+		return fmt.Sprintf("%s: not matched by the annotation selector", name)
+	case MatchReasonFieldSelector:
+		return fmt.Sprintf("%s: not matched by the field selector", name)
+	case MatchReasonNamespace:
+		return fmt.Sprintf("%s: not in a selected namespace", name)
+	case MatchReasonOwner:
+		return fmt.Sprintf("%s: not matched by the owner filter", name)
+	case MatchReasonAge:
+		return fmt.Sprintf("%s: not matched by the age filter", name)
+	case MatchReasonFieldRequirement:
+		return fmt.Sprintf("%s: does not satisfy the field requirement '%s'", name, e.Pattern)
+	case MatchReasonExpression:
+		return fmt.Sprintf("%s: not matched by the expression", name)
+	case MatchReasonEmptySelector:
+		return fmt.Sprintf("%s: not matched, since the namespace selector is empty", name)
+	case MatchReasonTerminating:
+		return fmt.Sprintf("%s: not matched, since the namespace is terminating", name)
+	default:
+		return fmt.Sprintf("%s: included=%v, reason=%s", name, e.Included, e.Reason)
+	}
+}
+
+// ExplainMatches returns an explanation for every object of the kind in the provided ResourceList,
+// in the Target's Namespace if it is set (otherwise in any namespace), describing whether the
+// Target matches it and which rule decided the result. The rules are evaluated in this order: the
+// namespace scoping, the LabelSelector, the AnnotationSelector, the FieldSelector, the Include
+// list, the Exclude list, the owner filters, the age filters, the FieldRequirements, and finally
+// the Expression; the first rule which does not match the object is given as the reason. The
+// objects which are included are exactly the ones returned by `GetMatches`.
+//
+// Since this lists every object of the kind (in the namespace), and evaluates the selectors on the
+// client, it is more expensive than `GetMatches` and is meant to help with debugging.
+func (t Target) ExplainMatches(
+	ctx context.Context, r client.Reader, list ResourceList, opts ...MatchOption,
+) ([]MatchExplanation, error) {
+	compiled, err := t.Compile()
+	if err != nil {
+		return nil, err
+	}
+
+	return compiled.ExplainMatches(ctx, r, list, opts...)
+}
+
+// ExplainMatches returns an explanation for every object of the kind in the provided ResourceList,
+// describing whether the compiled Target matches it. See `Target.ExplainMatches` for more details.
+func (ct *CompiledTarget) ExplainMatches(
+	ctx context.Context, r client.Reader, list ResourceList, opts ...MatchOption,
+) ([]MatchExplanation, error) {
+	options := newMatchOptions(opts)
+
+	var selectedNamespaces map[string]bool
+
+	if ct.namespaceSelector != nil {
+		nsReader := options.namespaceReader
+		if nsReader == nil {
+			nsReader = r
+		}
+
+		namespaces, err := ct.namespaceSelector.GetNamespaces(ctx, nsReader, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		selectedNamespaces = make(map[string]bool, len(namespaces))
+		for _, ns := range namespaces {
+			selectedNamespaces[ns] = true
+		}
+	}
+
+	now := options.clock.Now()
+
+	listPage := func(continueToken string, limit int64, _ bool) ([]client.Object, string, error) {
+		listOpts := client.ListOptions{
+			Namespace: ct.target.Namespace,
+			Limit:     limit,
+			Continue:  continueToken,
+		}
+
+		if err := r.List(ctx, list.ObjectList(), &listOpts); err != nil {
+			return nil, "", err
+		}
+
+		items, err := list.Items()
+		if err != nil {
+			return nil, "", err
+		}
+
+		return items, list.ObjectList().GetContinue(), nil
+	}
+
+	explainPage := func(items []client.Object, _ bool) ([]MatchExplanation, error) {
+		explanations := make([]MatchExplanation, 0, len(items))
+
+		if options.pageSize > 0 {
+			copyObjects(items)
+		}
+
+		for _, item := range items {
+			explanation, err := ct.explainObject(item, selectedNamespaces, now)
+			if err != nil {
+				return nil, err
+			}
+
+			explanations = append(explanations, explanation)
+		}
+
+		return explanations, nil
+	}
+
+	return listMatchesInPages(ctx, "", options.pageSize, listPage, explainPage)
+}
+
+// ExplainObject returns an explanation of whether the compiled Target matches the single object,
+// without making any API calls. When the Target has a NamespaceSelector, the names of the
+// namespaces it selects (for example, from `GetNamespaces`) must be provided; otherwise they are
+// ignored. The age filters are evaluated at the time given by the clock in the MatchOptions.
+func (ct *CompiledTarget) ExplainObject(
+	obj client.Object, selectedNamespaces []string, opts ...MatchOption,
+) (MatchExplanation, error) {
+	var selected map[string]bool
+
+	if ct.namespaceSelector != nil {
+		selected = make(map[string]bool, len(selectedNamespaces))
+		for _, ns := range selectedNamespaces {
+			selected[ns] = true
+		}
+	}
+
+	return ct.explainObject(obj, selected, newMatchOptions(opts).clock.Now())
+}
+
+// explainObject evaluates the compiled Target's rules against the object, in the order described
+// in `Target.ExplainMatches`. The selectedNamespaces should be nil if the Target has no
+// NamespaceSelector, and the age filters are evaluated at the time `now`.
+func (ct *CompiledTarget) explainObject(
+	obj client.Object, selectedNamespaces map[string]bool, now time.Time,
+) (MatchExplanation, error) {
+	explanation := MatchExplanation{Object: obj}
+
+	if ct.target.Namespace != "" && obj.GetNamespace() != ct.target.Namespace {
+		explanation.Reason = MatchReasonNamespace
+
+		return explanation, nil
+	}
+
+	if selectedNamespaces != nil && !selectedNamespaces[obj.GetNamespace()] {
+		explanation.Reason = MatchReasonNamespace
+
+		return explanation, nil
+	}
+
+	if !ct.labelSel.Matches(labels.Set(obj.GetLabels())) {
+		explanation.Reason = MatchReasonLabelSelector
+
+		return explanation, nil
+	}
+
+	return ct.filterObject(obj, true, now)
+}
+
+// filterObject evaluates the compiled Target's rules which are checked on the client for every
+// listed object: the AnnotationSelector, the FieldSelector (only when filterFields is true, since
+// it is usually applied by the API server), the Include and Exclude lists, the owner filters, the
+// age filters at the time `now`, the FieldRequirements, and the Expression, in that order. The
+// explanation gives the first rule which does not match the object. Every way of matching uses
+// this, so that `GetMatches`, `GetMatchesDynamic`, and `ExplainMatches` can not disagree.
+func (ct *CompiledTarget) filterObject(
+	obj client.Object, filterFields bool, now time.Time,
+) (MatchExplanation, error) {
+	explanation := MatchExplanation{Object: obj}
+
+	if !ct.annotations.matches(obj.GetAnnotations()) {
+		explanation.Reason = MatchReasonAnnotationSelector
+
+		return explanation, nil
+	}
+
+	if filterFields && ct.fieldSel != nil {
+		matched, err := fieldsMatch(ct.fieldSel, obj)
+		if err != nil {
+			return explanation, err
+		}
+
+		if !matched {
+			explanation.Reason = MatchReasonFieldSelector
+
+			return explanation, nil
+		}
+	}
+
+	var err error
+
+	explanation.Included, explanation.Reason, explanation.Pattern, err = ct.names.explain(obj.GetName())
+	if err != nil || !explanation.Included {
+		return explanation, err
+	}
+
+	ownerMatched, err := ct.owners.matches(obj.GetOwnerReferences())
+	if err != nil {
+		return explanation, err
+	}
+
+	if !ownerMatched {
+		return MatchExplanation{Object: obj, Reason: MatchReasonOwner}, nil
+	}
+
+	if !ct.age.matches(obj.GetCreationTimestamp(), now) {
+		return MatchExplanation{Object: obj, Reason: MatchReasonAge}, nil
+	}
+
+	if len(ct.requirements) == 0 && ct.expression == nil {
+		return explanation, nil
+	}
+
+	content, err := objectContent(obj)
+	if err != nil {
+		return explanation, err
+	}
+
+	if unmet := firstUnmetRequirement(ct.requirements, content); unmet != nil {
+		return MatchExplanation{
+			Object:  obj,
+			Reason:  MatchReasonFieldRequirement,
+			Pattern: unmet.requirement.String(),
+		}, nil
+	}
+
+	if ct.expression != nil {
+		matched, err := matchesExpression(ct.expression, obj.GetName(), content)
+		if err != nil {
+			return explanation, err
+		}
+
+		if !matched {
+			explanation = MatchExplanation{Object: obj, Reason: MatchReasonExpression}
+		}
+	}
+
+	return explanation, nil
+}
+
+// ExplainNamespaces returns an explanation for every namespace on the cluster, describing whether
+// the NamespaceSelector matches it and which rule decided the result. The namespaces which are
+// included are exactly the ones returned by `GetNamespaces` with the same options. See
+// `Target.ExplainMatches` for more details.
+func (sel NamespaceSelector) ExplainNamespaces(
+	ctx context.Context, r client.Reader, opts ...MatchOption,
+) ([]MatchExplanation, error) {
+	compiled, err := sel.Compile()
+	if err != nil {
+		return nil, err
+	}
+
+	return compiled.ExplainNamespaces(ctx, r, opts...)
+}
+
+// ExplainNamespaces returns an explanation for every namespace on the cluster, describing whether
+// the compiled NamespaceSelector matches it. See `NamespaceSelector.ExplainNamespaces` for more
+// details.
+func (cs *CompiledNamespaceSelector) ExplainNamespaces(
+	ctx context.Context, r client.Reader, opts ...MatchOption,
+) ([]MatchExplanation, error) {
+	explanations, err := cs.target.ExplainMatches(ctx, r, &namespaceResList{}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if cs.empty {
+		// A somewhat special case of no matches.
+		for i := range explanations {
+			explanations[i] = MatchExplanation{
+				Object: explanations[i].Object,
+				Reason: MatchReasonEmptySelector,
+			}
+		}
+	}
+
+	if newMatchOptions(opts).excludeTerminating {
+		for i := range explanations {
+			explanations[i] = withoutTerminating(explanations[i])
+		}
+	}
+
+	return explanations, nil
+}
+
+// ExplainNamespace returns an explanation of whether the compiled NamespaceSelector matches the
+// single namespace, without making any API calls. Like `GetNamespaces`, an empty selector matches
+// nothing.
+func (cs *CompiledNamespaceSelector) ExplainNamespace(
+	ns client.Object, opts ...MatchOption,
+) (MatchExplanation, error) {
+	if cs.empty {
+		return MatchExplanation{Object: ns, Reason: MatchReasonEmptySelector}, nil
+	}
+
+	explanation, err := cs.target.ExplainObject(ns, nil, opts...)
+	if err != nil || !newMatchOptions(opts).excludeTerminating {
+		return explanation, err
+	}
+
+	return withoutTerminating(explanation), nil
+}
+
+// withoutTerminating changes the explanation of an included namespace which is being deleted, so
+// that it is not included.
+func withoutTerminating(explanation MatchExplanation) MatchExplanation {
+	if explanation.Included && namespaceTerminating(explanation.Object) {
+		return MatchExplanation{Object: explanation.Object, Reason: MatchReasonTerminating}
+	}
+
+	return explanation
+}
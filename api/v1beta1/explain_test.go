@@ -0,0 +1,478 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// explanationSummary is a simplified form of a MatchExplanation, for easier comparisons.
+type explanationSummary struct {
+	Included bool
+	Reason   MatchReason
+	Pattern  string
+}
+
+func summarize(explanations []MatchExplanation) map[string]explanationSummary {
+	summaries := make(map[string]explanationSummary, len(explanations))
+
+	for _, e := range explanations {
+		key := e.Object.GetName()
+		if e.Object.GetNamespace() != "" {
+			key = e.Object.GetNamespace() + "/" + key
+		}
+
+		summaries[key] = explanationSummary{Included: e.Included, Reason: e.Reason, Pattern: e.Pattern}
+	}
+
+	return summaries
+}
+
+func TestExplainMatches(t *testing.T) {
+	t.Parallel()
+
+	excluded := func(pattern string) explanationSummary {
+		return explanationSummary{Reason: MatchReasonExcluded, Pattern: pattern}
+	}
+	included := func(pattern string) explanationSummary {
+		return explanationSummary{Included: true, Reason: MatchReasonIncluded, Pattern: pattern}
+	}
+	notIncluded := explanationSummary{Reason: MatchReasonNotIncluded}
+	byLabel := explanationSummary{Reason: MatchReasonLabelSelector}
+	byField := explanationSummary{Reason: MatchReasonFieldSelector}
+	byAnnotation := explanationSummary{Reason: MatchReasonAnnotationSelector}
+	byExpression := explanationSummary{Reason: MatchReasonExpression}
+	byOwner := explanationSummary{Reason: MatchReasonOwner}
+	byAge := explanationSummary{Reason: MatchReasonAge}
+	byRequirement := explanationSummary{Reason: MatchReasonFieldRequirement, Pattern: "data.tier In [silver]"}
+
+	tests := map[string]struct {
+		target Target
+		want   map[string]explanationSummary
+	}{
+		"include and exclude patterns": {
+			target: Target{Include: []NonEmptyString{"b*", "kube-*"}, Exclude: []NonEmptyString{"boo", "*-t*"}},
+			want: map[string]explanationSummary{
+				"default/foo":        notIncluded,
+				"default/bar":        included("b*"),
+				"default/baz":        included("b*"),
+				"default/boo":        excluded("boo"),
+				"default/default":    notIncluded,
+				"default/kube-one":   included("kube-*"),
+				"default/kube-two":   excluded("*-t*"),
+				"default/kube-three": excluded("*-t*"),
+			},
+		},
+		"label selector is checked before the names": {
+			target: Target{
+				LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key:      "sample",
+					Operator: metav1.LabelSelectorOpNotIn,
+					Values:   []string{"bar", "baz"},
+				}}},
+				Exclude: []NonEmptyString{"ba*", "kube-*"},
+			},
+			want: map[string]explanationSummary{
+				"default/foo":        included(""),
+				"default/bar":        byLabel,
+				"default/baz":        byLabel,
+				"default/boo":        included(""),
+				"default/default":    included(""),
+				"default/kube-one":   excluded("kube-*"),
+				"default/kube-two":   excluded("kube-*"),
+				"default/kube-three": excluded("kube-*"),
+			},
+		},
+		"field selector and regex patterns": {
+			target: Target{
+				FieldSelector: "data.tier=silver",
+				Include:       []NonEmptyString{"kube-(one|two)", "f.*"},
+				MatchMode:     RegexMatchMode,
+			},
+			want: map[string]explanationSummary{
+				"default/foo":        included("f.*"),
+				"default/bar":        byField,
+				"default/baz":        byField,
+				"default/boo":        byField,
+				"default/default":    notIncluded,
+				"default/kube-one":   included("kube-(one|two)"),
+				"default/kube-two":   included("kube-(one|two)"),
+				"default/kube-three": notIncluded,
+			},
+		},
+		"expression is checked after the names": {
+			target: Target{
+				Exclude:    []NonEmptyString{"kube-*"},
+				Expression: `object.data.tier == "gold"`,
+			},
+			want: map[string]explanationSummary{
+				"default/foo":        byExpression,
+				"default/bar":        included(""),
+				"default/baz":        included(""),
+				"default/boo":        included(""),
+				"default/default":    byExpression,
+				"default/kube-one":   excluded("kube-*"),
+				"default/kube-two":   excluded("kube-*"),
+				"default/kube-three": excluded("kube-*"),
+			},
+		},
+		"field requirements are checked after the names": {
+			target: Target{
+				Include: []NonEmptyString{"b*", "kube-*"},
+				FieldRequirements: []FieldRequirement{
+					{Path: "data.tier", Operator: FieldOpIn, Values: []string{"silver"}},
+				},
+				Expression: `object.metadata.name != "kube-two"`,
+			},
+			want: map[string]explanationSummary{
+				"default/foo":        notIncluded,
+				"default/bar":        byRequirement,
+				"default/baz":        byRequirement,
+				"default/boo":        byRequirement,
+				"default/default":    notIncluded,
+				"default/kube-one":   included("kube-*"),
+				"default/kube-two":   byExpression,
+				"default/kube-three": included("kube-*"),
+			},
+		},
+		"annotation selector is checked after the label selector": {
+			target: Target{
+				LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key:      "sample",
+					Operator: metav1.LabelSelectorOpNotIn,
+					Values:   []string{"kube-one"},
+				}}},
+				AnnotationSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key:      "policy.example.com/exempt",
+					Operator: metav1.LabelSelectorOpDoesNotExist,
+				}}},
+				Exclude: []NonEmptyString{"b*"},
+			},
+			want: map[string]explanationSummary{
+				"default/foo":        included(""),
+				"default/bar":        excluded("b*"),
+				"default/baz":        excluded("b*"),
+				"default/boo":        excluded("b*"),
+				"default/default":    included(""),
+				"default/kube-one":   byLabel,
+				"default/kube-two":   byAnnotation,
+				"default/kube-three": byAnnotation,
+			},
+		},
+		"owner filter is checked after the names": {
+			target: Target{
+				Owner:   &OwnerSelector{Kind: "Secret"},
+				Include: []NonEmptyString{"b*", "foo"},
+			},
+			want: map[string]explanationSummary{
+				"default/foo":        byOwner,
+				"default/bar":        byOwner,
+				"default/baz":        included("b*"),
+				"default/boo":        byOwner,
+				"default/default":    notIncluded,
+				"default/kube-one":   notIncluded,
+				"default/kube-two":   notIncluded,
+				"default/kube-three": notIncluded,
+			},
+		},
+		"age filters are checked after the names": {
+			target: Target{
+				OlderThan: &metav1.Duration{Duration: 5 * time.Minute},
+				NewerThan: &metav1.Duration{Duration: 90 * 24 * time.Hour},
+				Exclude:   []NonEmptyString{"bar"},
+			},
+			want: map[string]explanationSummary{
+				"default/foo":        byAge,
+				"default/bar":        excluded("bar"),
+				"default/baz":        included(""),
+				"default/boo":        byAge,
+				"default/default":    byAge,
+				"default/kube-one":   byAge,
+				"default/kube-two":   byAge,
+				"default/kube-three": byAge,
+			},
+		},
+		"only the Target's namespace is listed": {
+			target: Target{Namespace: "kube-system", Exclude: []NonEmptyString{"*"}},
+			want:   map[string]explanationSummary{},
+		},
+	}
+
+	withClock := WithClock(clocktesting.NewFakePassiveClock(sampleNow))
+
+	for name, tcase := range tests {
+		objs := detailedConfigMaps()
+		fakeClient := fake.NewClientBuilder().WithRuntimeObjects(objs...).Build()
+
+		got, err := tcase.target.ExplainMatches(context.TODO(), fakeClient, &configMapResList{}, withClock)
+		if err != nil {
+			t.Errorf("Unexpected error '%v', in test '%v'", err, name)
+		}
+
+		if diff := cmp.Diff(tcase.want, summarize(got)); diff != "" {
+			t.Errorf("Mismatch in test '%v': %v", name, diff)
+		}
+
+		matches, err := tcase.target.GetMatches(context.TODO(), fakeClient, &configMapResList{}, withClock)
+		if err != nil {
+			t.Errorf("Unexpected error '%v' from GetMatches, in test '%v'", err, name)
+		}
+
+		for _, match := range matches {
+			if !tcase.want[match.GetNamespace()+"/"+match.GetName()].Included {
+				t.Errorf("Object '%v' was matched by GetMatches but not explained as included in test '%v'",
+					match.GetName(), name)
+			}
+		}
+	}
+}
+
+func TestExplainMatchesNamespaceSelector(t *testing.T) {
+	t.Parallel()
+
+	fakeClient := fake.NewClientBuilder().WithRuntimeObjects(sampleNamespacedObjects()...).Build()
+
+	target := Target{
+		NamespaceSelector: &NamespaceSelector{Include: []NonEmptyString{"team-*"}},
+		Exclude:           []NonEmptyString{"db"},
+	}
+
+	got, err := target.ExplainMatches(context.TODO(), fakeClient, &configMapResList{})
+	if err != nil {
+		t.Fatalf("Unexpected error '%v'", err)
+	}
+
+	for key, summary := range summarize(got) {
+		switch key {
+		case "team-a/app", "team-b/app", "team-c/app":
+			if !summary.Included || summary.Reason != MatchReasonIncluded {
+				t.Errorf("Expected '%v' to be included, got %+v", key, summary)
+			}
+		case "team-a/db", "team-b/db", "team-c/db":
+			if summary.Included || summary.Reason != MatchReasonExcluded {
+				t.Errorf("Expected '%v' to be excluded, got %+v", key, summary)
+			}
+		default:
+			if summary.Included || summary.Reason != MatchReasonNamespace {
+				t.Errorf("Expected '%v' to be outside the selected namespaces, got %+v", key, summary)
+			}
+		}
+	}
+}
+
+func TestExplainMatchesNamespace(t *testing.T) {
+	t.Parallel()
+
+	fakeClient := fake.NewClientBuilder().WithRuntimeObjects(sampleNamespacedObjects()...).Build()
+
+	target := Target{Namespace: "team-a", Exclude: []NonEmptyString{"db"}}
+
+	got, err := target.ExplainMatches(context.TODO(), fakeClient, &configMapResList{})
+	if err != nil {
+		t.Fatalf("Unexpected error '%v'", err)
+	}
+
+	want := map[string]explanationSummary{
+		"team-a/app": {Included: true, Reason: MatchReasonIncluded},
+		"team-a/db":  {Reason: MatchReasonExcluded, Pattern: "db"},
+	}
+
+	if diff := cmp.Diff(want, summarize(got)); diff != "" {
+		t.Errorf("Mismatch in the explanations: %v", diff)
+	}
+}
+
+func TestExplainObject(t *testing.T) {
+	t.Parallel()
+
+	compiled, err := Target{
+		NamespaceSelector: &NamespaceSelector{Include: []NonEmptyString{"team-*"}},
+		Exclude:           []NonEmptyString{"db"},
+	}.Compile()
+	if err != nil {
+		t.Fatalf("Unexpected error compiling the Target: '%v'", err)
+	}
+
+	selected := []string{"team-a", "team-b"}
+
+	tests := map[string]struct {
+		obj        *corev1.ConfigMap
+		wantReason MatchReason
+	}{
+		"in a selected namespace": {
+			obj:        &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-a"}},
+			wantReason: MatchReasonIncluded,
+		},
+		"excluded by name": {
+			obj:        &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "team-b"}},
+			wantReason: MatchReasonExcluded,
+		},
+		"outside the provided namespaces": {
+			obj:        &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-c"}},
+			wantReason: MatchReasonNamespace,
+		},
+	}
+
+	for name, tcase := range tests {
+		got, err := compiled.ExplainObject(tcase.obj, selected)
+		if err != nil {
+			t.Errorf("Unexpected error '%v', in test '%v'", err, name)
+		}
+
+		if got.Reason != tcase.wantReason || got.Included != (tcase.wantReason == MatchReasonIncluded) {
+			t.Errorf("Expected reason '%v' in test '%v', got %+v", tcase.wantReason, name, got)
+		}
+	}
+
+	got, err := compiled.ExplainObject(tests["in a selected namespace"].obj, nil)
+	if err != nil || got.Included {
+		t.Errorf("Expected no match without any selected namespaces, got %+v, '%v'", got, err)
+	}
+}
+
+func TestExplainNamespace(t *testing.T) {
+	t.Parallel()
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"team": "a"}}}
+
+	tests := map[string]struct {
+		sel        NamespaceSelector
+		wantReason MatchReason
+	}{
+		"included by name": {
+			sel:        NamespaceSelector{Include: []NonEmptyString{"team-*"}},
+			wantReason: MatchReasonIncluded,
+		},
+		"not matched by the label selector": {
+			sel: NamespaceSelector{LabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"team": "b"},
+			}},
+			wantReason: MatchReasonLabelSelector,
+		},
+		"empty selector": {
+			sel:        NamespaceSelector{},
+			wantReason: MatchReasonEmptySelector,
+		},
+	}
+
+	for name, tcase := range tests {
+		compiled, err := tcase.sel.Compile()
+		if err != nil {
+			t.Fatalf("Unexpected error compiling the selector in test '%v': '%v'", name, err)
+		}
+
+		got, err := compiled.ExplainNamespace(ns)
+		if err != nil {
+			t.Errorf("Unexpected error '%v', in test '%v'", err, name)
+		}
+
+		if got.Reason != tcase.wantReason || got.Included != (tcase.wantReason == MatchReasonIncluded) {
+			t.Errorf("Expected reason '%v' in test '%v', got %+v", tcase.wantReason, name, got)
+		}
+	}
+}
+
+func TestExplainNamespaces(t *testing.T) {
+	t.Parallel()
+
+	fakeClient := fake.NewClientBuilder().WithRuntimeObjects(sampleNamespacedObjects()...).Build()
+
+	tests := map[string]struct {
+		sel  NamespaceSelector
+		want map[string]explanationSummary
+	}{
+		"empty selector": {
+			sel: NamespaceSelector{Exclude: []NonEmptyString{"kube-*"}},
+			want: map[string]explanationSummary{
+				"default":     {Reason: MatchReasonEmptySelector},
+				"kube-system": {Reason: MatchReasonEmptySelector},
+				"team-a":      {Reason: MatchReasonEmptySelector},
+				"team-b":      {Reason: MatchReasonEmptySelector},
+				"team-c":      {Reason: MatchReasonEmptySelector},
+			},
+		},
+		"label selector and exclude": {
+			sel: NamespaceSelector{
+				LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key:      "team",
+					Operator: metav1.LabelSelectorOpExists,
+				}}},
+				Exclude: []NonEmptyString{"team-b"},
+			},
+			want: map[string]explanationSummary{
+				"default":     {Reason: MatchReasonLabelSelector},
+				"kube-system": {Reason: MatchReasonLabelSelector},
+				"team-a":      {Included: true, Reason: MatchReasonIncluded},
+				"team-b":      {Reason: MatchReasonExcluded, Pattern: "team-b"},
+				"team-c":      {Included: true, Reason: MatchReasonIncluded},
+			},
+		},
+	}
+
+	for name, tcase := range tests {
+		got, err := tcase.sel.ExplainNamespaces(context.TODO(), fakeClient)
+		if err != nil {
+			t.Errorf("Unexpected error '%v', in test '%v'", err, name)
+		}
+
+		if diff := cmp.Diff(tcase.want, summarize(got)); diff != "" {
+			t.Errorf("Mismatch in test '%v': %v", name, diff)
+		}
+	}
+}
+
+func TestMatchExplanationString(t *testing.T) {
+	t.Parallel()
+
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}}
+
+	tests := []struct {
+		input MatchExplanation
+		want  string
+	}{{
+		input: MatchExplanation{Object: cm, Included: true, Reason: MatchReasonIncluded, Pattern: "f*"},
+		want:  "default/foo: included by the include pattern 'f*'",
+	}, {
+		input: MatchExplanation{Object: cm, Included: true, Reason: MatchReasonIncluded},
+		want:  "default/foo: included, since there are no include patterns",
+	}, {
+		input: MatchExplanation{Object: ns, Reason: MatchReasonExcluded, Pattern: "kube-*"},
+		want:  "kube-system: excluded by the exclude pattern 'kube-*'",
+	}, {
+		input: MatchExplanation{Object: cm, Reason: MatchReasonOwner},
+		want:  "default/foo: not matched by the owner filter",
+	}, {
+		input: MatchExplanation{Object: cm, Reason: MatchReasonAge},
+		want:  "default/foo: not matched by the age filter",
+	}, {
+		input: MatchExplanation{Object: cm, Reason: MatchReasonFieldRequirement, Pattern: "data.tier Exists"},
+		want:  "default/foo: does not satisfy the field requirement 'data.tier Exists'",
+	}, {
+		input: MatchExplanation{Object: cm, Reason: MatchReasonAnnotationSelector},
+		want:  "default/foo: not matched by the annotation selector",
+	}, {
+		input: MatchExplanation{Object: cm, Reason: MatchReasonExpression},
+		want:  "default/foo: not matched by the expression",
+	}, {
+		input: MatchExplanation{Object: ns, Reason: MatchReasonEmptySelector},
+		want:  "kube-system: not matched, since the namespace selector is empty",
+	}, {
+		input: MatchExplanation{Object: ns, Reason: MatchReasonTerminating},
+		want:  "kube-system: not matched, since the namespace is terminating",
+	}}
+
+	for _, tc := range tests {
+		if got := tc.input.String(); got != tc.want {
+			t.Errorf("Expected '%v', got '%v'", tc.want, got)
+		}
+	}
+}
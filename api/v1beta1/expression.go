@@ -0,0 +1,72 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+)
+
+// expressionCostLimit is the maximum runtime cost of evaluating a Target's Expression on a single
+// object, as measured by CEL. It is the same as the per-expression limit used by the Kubernetes API
+// server for CRD validation rules.
+const expressionCostLimit uint64 = 1000000
+
+// ErrExpressionNotBool is returned when a Target's Expression does not evaluate to a boolean.
+var ErrExpressionNotBool = errors.New("the expression must evaluate to a bool")
+
+// getExpressionEnv returns the CEL environment for Target expressions, where the object being
+// evaluated is available as the `object` variable. The environment is only created once.
+//
+//nolint:gochecknoglobals // the environment is expensive to create, and is safe to share
+var getExpressionEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(cel.Variable("object", cel.DynType))
+})
+
+// compileExpression parses and checks the CEL expression, and returns a program for evaluating it
+// with the cost limit. The returned program can be safely shared between goroutines.
+//
+//nolint:ireturn // cel.Program is the only way the CEL library provides programs
+func compileExpression(expression string) (cel.Program, error) {
+	env, err := getExpressionEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("error compiling 'expression': %w", issues.Err())
+	}
+
+	if !ast.OutputType().IsExactType(cel.BoolType) && !ast.OutputType().IsExactType(cel.DynType) {
+		return nil, fmt.Errorf("error compiling 'expression': %w, not %v", ErrExpressionNotBool, ast.OutputType())
+	}
+
+	prog, err := env.Program(ast, cel.CostLimit(expressionCostLimit))
+	if err != nil {
+		return nil, fmt.Errorf("error compiling 'expression': %w", err)
+	}
+
+	return prog, nil
+}
+
+// matchesExpression returns whether the unstructured content of an object satisfies the compiled
+// expression. An error is returned if the evaluation fails, for example when a field that does
+// not exist is accessed, or when the cost limit is exceeded.
+func matchesExpression(prog cel.Program, name string, content map[string]interface{}) (bool, error) {
+	val, _, err := prog.Eval(map[string]interface{}{"object": content})
+	if err != nil {
+		return false, fmt.Errorf("error evaluating 'expression' on '%s': %w", name, err)
+	}
+
+	matched, ok := val.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("error evaluating 'expression' on '%s': %w, not %v",
+			name, ErrExpressionNotBool, val.Type())
+	}
+
+	return matched, nil
+}
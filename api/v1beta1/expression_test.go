@@ -0,0 +1,27 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestExpressionCostLimit(t *testing.T) {
+	t.Parallel()
+
+	// Each comprehension multiplies the cost by the length of the list, which quickly exceeds the limit.
+	expression := `[1,2,3,4,5,6,7,8,9,10].all(a, [1,2,3,4,5,6,7,8,9,10].all(b, ` +
+		`[1,2,3,4,5,6,7,8,9,10].all(c, [1,2,3,4,5,6,7,8,9,10].all(d, ` +
+		`[1,2,3,4,5,6,7,8,9,10].all(e, [1,2,3,4,5,6,7,8,9,10].all(f, object.data.tier != ""))))))`
+
+	cl := fake.NewClientBuilder().WithRuntimeObjects(sampleConfigMaps()...).Build()
+
+	_, err := (Target{Expression: expression}).GetMatches(context.TODO(), cl, &configMapResList{})
+	if err == nil || !strings.Contains(err.Error(), "operation cancelled: actual cost limit exceeded") {
+		t.Errorf("Expected a cost limit error, got '%v'", err)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//+kubebuilder:object:generate=false
+
+// MatchOption configures optional behavior of the methods which find matching objects on the
+// cluster, like `Target.GetMatches` and `NamespaceSelector.GetNamespaces`.
+type MatchOption func(*matchOptions)
+
+//+kubebuilder:object:generate=false
+
+type matchOptions struct {
+	pageSize           int64
+	namespaceReader    client.Reader
+	clock              clock.PassiveClock
+	excludeTerminating bool
+}
+
+func newMatchOptions(opts []MatchOption) matchOptions {
+	options := matchOptions{clock: clock.RealClock{}}
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options
+}
+
+// WithPageSize makes the matching methods list the objects in chunks of (at most) the given size,
+// using the Limit and Continue list options, instead of listing everything in one request. The
+// name filters are applied to each chunk, so only matching objects are kept in memory. If a
+// continue token expires while listing, the listing is restarted from the beginning. A size of 0
+// (the default) disables chunking.
+//
+// A cached client.Reader, like the default client of a controller-runtime Manager, does not support
+// chunking: it truncates the list without a continue token. When the first chunk looks like that,
+// it is listed again without a limit, so the results are still complete. To actually save memory,
+// this option should be used with an uncached Reader, like the one provided by `GetAPIReader` on a
+// controller-runtime Manager.
+func WithPageSize(size int64) MatchOption {
+	return func(o *matchOptions) {
+		o.pageSize = size
+	}
+}
+
+// WithNamespaceReader sets the client.Reader used to find the namespaces selected by a Target's
+// NamespaceSelector. It is required for `Target.GetMatchesDynamic` when the Target has a
+// NamespaceSelector; in `Target.GetMatches`, the given Reader is used by default.
+func WithNamespaceReader(r client.Reader) MatchOption {
+	return func(o *matchOptions) {
+		o.namespaceReader = r
+	}
+}
+
+// WithClock sets the clock used to find the current time, when evaluating the OlderThan and
+// NewerThan filters of a Target. By default, the real system clock is used; this is mainly intended
+// to make tests deterministic, for example with a `k8s.io/utils/clock/testing.FakePassiveClock`.
+func WithClock(c clock.PassiveClock) MatchOption {
+	return func(o *matchOptions) {
+		o.clock = c
+	}
+}
+
+// ExcludeTerminatingNamespaces makes NamespaceSelectors leave out the namespaces which are being
+// deleted, even when they would otherwise match. Objects can not be created in those namespaces, so
+// this is useful for policies which create objects in the selected namespaces. It also applies to
+// the namespaces selected by a Target's NamespaceSelector.
+func ExcludeTerminatingNamespaces() MatchOption {
+	return func(o *matchOptions) {
+		o.excludeTerminating = true
+	}
+}
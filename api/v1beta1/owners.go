@@ -0,0 +1,120 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	"errors"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// OwnerSelector matches the owner references of an object. Every field which is set must match the
+// same owner reference, so an empty OwnerSelector matches any object which has an owner.
+type OwnerSelector struct {
+	// Kind is the kind of the owner, for example 'ReplicaSet'.
+	Kind string `json:"kind,omitempty"`
+
+	// Name is a pattern for the name of the owner, interpreted according to the MatchMode of the
+	// Target. For example, the Pods of a Deployment named 'web' could be matched with the Kind
+	// 'ReplicaSet' and the Name 'web-*'.
+	Name string `json:"name,omitempty"`
+
+	// UID is the UID of the owner.
+	UID types.UID `json:"uid,omitempty"`
+}
+
+// ErrInvalidOwnerFilter is returned when a Target has both an Owner and NoOwner set, since no object
+// could match both of them, or when the Owner's Name pattern is malformed.
+var ErrInvalidOwnerFilter = errors.New("invalid owner filter")
+
+// ownerMatcher evaluates the Owner and NoOwner filters of a Target.
+type ownerMatcher struct {
+	noOwner bool
+	owner   *OwnerSelector
+	name    compiledPattern
+}
+
+// newOwnerMatcher checks the owner filters, and compiles the Name pattern of the OwnerSelector
+// according to the MatchMode. It returns nil if neither filter is set.
+func newOwnerMatcher(owner *OwnerSelector, noOwner bool, mode MatchMode) (*ownerMatcher, error) {
+	if owner == nil && !noOwner {
+		return nil, nil //nolint:nilnil // a nil matcher is valid, and matches everything
+	}
+
+	if errs := validateOwnerFilters(owner, noOwner, mode, nil); len(errs) != 0 {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOwnerFilter, errs.ToAggregate())
+	}
+
+	matcher := &ownerMatcher{noOwner: noOwner, owner: owner}
+
+	if owner != nil && owner.Name != "" {
+		matcher.name = compilePatterns(mode, []NonEmptyString{NonEmptyString(owner.Name)})[0]
+	}
+
+	return matcher, nil
+}
+
+// matches returns whether the owner references satisfy the filters. A nil matcher matches
+// everything. Errors from the Name pattern are returned like the errors from the Include and
+// Exclude patterns.
+func (m *ownerMatcher) matches(refs []metav1.OwnerReference) (bool, error) {
+	if m == nil {
+		return true, nil
+	}
+
+	if m.noOwner {
+		return len(refs) == 0, nil
+	}
+
+	for _, ref := range refs {
+		if m.owner.Kind != "" && ref.Kind != m.owner.Kind {
+			continue
+		}
+
+		if m.owner.UID != "" && ref.UID != m.owner.UID {
+			continue
+		}
+
+		if m.owner.Name != "" {
+			matched, err := m.name.match(ref.Name)
+			if err != nil {
+				return false, fmt.Errorf("error parsing 'owner.name' pattern '%s': %w", m.owner.Name, err)
+			}
+
+			if !matched {
+				continue
+			}
+		}
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// validateOwnerFilters checks that the Owner and NoOwner filters are not both set, and that the
+// Owner's Name pattern can be compiled according to the MatchMode. All problems are returned, with
+// paths relative to the given fldPath (the path of the Target).
+func validateOwnerFilters(owner *OwnerSelector, noOwner bool, mode MatchMode, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if owner == nil {
+		return allErrs
+	}
+
+	if noOwner {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("noOwner"),
+			"may not be set at the same time as the owner"))
+	}
+
+	if owner.Name != "" {
+		if err := compilePattern(mode, owner.Name); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("owner", "name"), owner.Name, err.Error()))
+		}
+	}
+
+	return allErrs
+}
@@ -0,0 +1,25 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestOwnerMatcherPatternError(t *testing.T) {
+	t.Parallel()
+
+	matcher := &ownerMatcher{
+		owner: &OwnerSelector{Name: "web-*["},
+		name:  compilePatterns(GlobMatchMode, []NonEmptyString{"web-*["})[0],
+	}
+
+	_, err := matcher.matches([]metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-abc"}})
+	if !errors.Is(err, filepath.ErrBadPattern) {
+		t.Errorf("Expected a filepath.ErrBadPattern, got '%v'", err)
+	}
+}
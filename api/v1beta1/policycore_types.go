@@ -62,11 +62,22 @@ func (ra RemediationAction) IsInform() bool {
 type NamespaceSelector struct {
 	*metav1.LabelSelector `json:",inline"`
 
-	// Include is a list of filepath expressions for namespaces the policy should apply to.
+	// AnnotationSelector restricts the selected namespaces to the ones whose annotations match the
+	// selector, with the same semantics as the LabelSelector. Since it only narrows the selection,
+	// setting it does not make an otherwise empty NamespaceSelector match any namespaces.
+	AnnotationSelector *metav1.LabelSelector `json:"annotationSelector,omitempty"`
+
+	// Include is a list of patterns for namespaces the policy should apply to. By default, these
+	// are filepath expressions; this can be changed with the MatchMode.
 	Include []NonEmptyString `json:"include,omitempty"`
 
-	// Exclude is a list of filepath expressions for namespaces the policy should _not_ apply to.
+	// Exclude is a list of patterns for namespaces the policy should _not_ apply to. By default,
+	// these are filepath expressions; this can be changed with the MatchMode.
 	Exclude []NonEmptyString `json:"exclude,omitempty"`
+
+	// MatchMode determines how the Include and Exclude patterns are interpreted. Accepted values
+	// include: Glob (the default, where the patterns are filepath expressions), and Regex.
+	MatchMode MatchMode `json:"matchMode,omitempty"`
 }
 
 // MarshalJSON returns the JSON encoding of the NamespaceSelector. The LabelSelector's matchLabels
@@ -75,24 +86,32 @@ type NamespaceSelector struct {
 func (sel NamespaceSelector) MarshalJSON() ([]byte, error) {
 	if sel.LabelSelector == nil {
 		return json.Marshal(struct {
-			Include []NonEmptyString `json:"include,omitempty"`
-			Exclude []NonEmptyString `json:"exclude,omitempty"`
+			AnnotationSelector *metav1.LabelSelector `json:"annotationSelector,omitempty"`
+			Include            []NonEmptyString      `json:"include,omitempty"`
+			Exclude            []NonEmptyString      `json:"exclude,omitempty"`
+			MatchMode          MatchMode             `json:"matchMode,omitempty"`
 		}{
-			Include: sel.Include,
-			Exclude: sel.Exclude,
+			AnnotationSelector: sel.AnnotationSelector,
+			Include:            sel.Include,
+			Exclude:            sel.Exclude,
+			MatchMode:          sel.MatchMode,
 		})
 	}
 
 	return json.Marshal(struct {
-		MatchLabels      map[string]string                 `json:"matchLabels"`
-		MatchExpressions []metav1.LabelSelectorRequirement `json:"matchExpressions"`
-		Include          []NonEmptyString                  `json:"include,omitempty"`
-		Exclude          []NonEmptyString                  `json:"exclude,omitempty"`
+		MatchLabels        map[string]string                 `json:"matchLabels"`
+		MatchExpressions   []metav1.LabelSelectorRequirement `json:"matchExpressions"`
+		AnnotationSelector *metav1.LabelSelector             `json:"annotationSelector,omitempty"`
+		Include            []NonEmptyString                  `json:"include,omitempty"`
+		Exclude            []NonEmptyString                  `json:"exclude,omitempty"`
+		MatchMode          MatchMode                         `json:"matchMode,omitempty"`
 	}{
-		MatchLabels:      sel.MatchLabels,
-		MatchExpressions: sel.MatchExpressions,
-		Include:          sel.Include,
-		Exclude:          sel.Exclude,
+		MatchLabels:        sel.MatchLabels,
+		MatchExpressions:   sel.MatchExpressions,
+		AnnotationSelector: sel.AnnotationSelector,
+		Include:            sel.Include,
+		Exclude:            sel.Exclude,
+		MatchMode:          sel.MatchMode,
 	})
 }
 
@@ -102,29 +121,44 @@ func (sel NamespaceSelector) MarshalJSON() ([]byte, error) {
 // `//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch`
 //
 // NOTE: unlike Target, an empty NamespaceSelector will match zero namespaces.
-func (sel NamespaceSelector) GetNamespaces(ctx context.Context, r client.Reader) ([]string, error) {
-	if len(sel.Include) == 0 && sel.LabelSelector == nil {
-		// A somewhat special case of no matches.
-		return []string{}, nil
+func (sel NamespaceSelector) GetNamespaces(
+	ctx context.Context, r client.Reader, opts ...MatchOption,
+) ([]string, error) {
+	compiled, err := sel.Compile()
+	if err != nil {
+		return nil, err
 	}
 
-	t := Target{
-		LabelSelector: sel.LabelSelector,
-		Include:       sel.Include,
-		Exclude:       sel.Exclude,
-	}
+	return compiled.GetNamespaces(ctx, r, opts...)
+}
 
-	matchingNamespaces, err := t.GetMatches(ctx, r, &namespaceResList{})
+// GetNamespaceObjects fetches all namespaces in the cluster and returns the namespaces that match
+// the NamespaceSelector. The results are the same as from GetNamespaces, but callers can look at
+// the full objects, for example their phase and labels, without another lookup.
+//
+// NOTE: unlike Target, an empty NamespaceSelector will match zero namespaces.
+func (sel NamespaceSelector) GetNamespaceObjects(
+	ctx context.Context, r client.Reader, opts ...MatchOption,
+) ([]corev1.Namespace, error) {
+	compiled, err := sel.Compile()
 	if err != nil {
 		return nil, err
 	}
 
-	names := make([]string, len(matchingNamespaces))
-	for i, ns := range matchingNamespaces {
-		names[i] = ns.GetName()
+	return compiled.GetNamespaceObjects(ctx, r, opts...)
+}
+
+// Matches returns whether the NamespaceSelector matches the given namespace, using only its name,
+// labels and annotations, without any API calls. The result is the same as whether the namespace
+// would be returned by GetNamespaces with the same options, so an empty NamespaceSelector will not
+// match it. An error is returned if the selector or one of its patterns is malformed.
+func (sel NamespaceSelector) Matches(ns client.Object, opts ...MatchOption) (bool, error) {
+	compiled, err := sel.Compile()
+	if err != nil {
+		return false, err
 	}
 
-	return names, nil
+	return compiled.Matches(ns, opts...)
 }
 
 type namespaceResList struct {
@@ -160,9 +194,49 @@ type PolicyCoreStatus struct {
 	// Accepted values include: Compliant, NonCompliant, and UnknownCompliancy
 	ComplianceState ComplianceState `json:"compliant,omitempty"`
 
+	// ObservedGeneration is the `metadata.generation` of the policy which was last evaluated by the
+	// controller.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
 	// Conditions represent the latest available observations of the object's status. One of these
 	// items should have Type=Compliant and a message detailing the current compliance.
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ComplianceHistory lists the most recent changes to the policy's compliance, newest first. At
+	// most MaxComplianceHistory entries are kept.
+	//+kubebuilder:validation:MaxItems=10
+	ComplianceHistory []ComplianceHistoryEntry `json:"complianceHistory,omitempty"`
+
+	// SelectedNamespaces records the namespaces which were selected by the NamespaceSelector, the
+	// last time the policy was evaluated.
+	SelectedNamespaces *SelectedNamespaces `json:"selectedNamespaces,omitempty"`
+}
+
+// SelectedNamespaces is a summary of the namespaces selected by a policy's NamespaceSelector. Only
+// some of the names are stored, so that the status stays small on clusters with many namespaces.
+type SelectedNamespaces struct {
+	// Count is the number of namespaces which were selected.
+	Count int32 `json:"count"`
+
+	// Namespaces is the alphabetical list of the selected namespaces, truncated to the first
+	// MaxRecordedNamespaces names when more namespaces were selected.
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// Hash is a hash of the full list of selected namespaces, which can be used to tell whether
+	// the selection changed, even when the list of Namespaces was truncated.
+	Hash string `json:"hash,omitempty"`
+}
+
+// ComplianceHistoryEntry records a change to the policy's compliance.
+type ComplianceHistoryEntry struct {
+	// Timestamp is when the compliance changed.
+	Timestamp metav1.Time `json:"timestamp"`
+
+	// ComplianceState is the compliance of the policy after the change.
+	ComplianceState ComplianceState `json:"complianceState"`
+
+	// Message is the message on the Compliant condition after the change.
+	Message string `json:"message,omitempty"`
 }
 
 //+kubebuilder:validation:Enum=Compliant;NonCompliant;UnknownCompliancy
@@ -218,7 +292,7 @@ type PolicyCore struct {
 //	}
 //
 //	func (f FakePolicy) ComplianceMessage() string {
-//		idx, compCond := f.Status.GetCondition("Compliant")
+//		idx, compCond := f.Status.GetCondition(nucleusv1beta1.ConditionTypeCompliant)
 //		if idx == -1 {
 //			return ""
 //		}
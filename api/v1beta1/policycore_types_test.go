@@ -2,7 +2,16 @@
 
 package v1beta1
 
-import "testing"
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
 
 func TestIsEnforce(t *testing.T) {
 	t.Parallel()
@@ -49,3 +58,88 @@ func TestIsInform(t *testing.T) {
 		}
 	}
 }
+
+func TestGetNamespaceObjectsExcludeTerminating(t *testing.T) {
+	t.Parallel()
+
+	terminating := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-d", Labels: map[string]string{"team": "d"}},
+		Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceTerminating},
+	}
+
+	objs := append([]runtime.Object{terminating}, sampleNamespacedObjects()...)
+	fakeClient := fake.NewClientBuilder().WithRuntimeObjects(objs...).Build()
+
+	sel := NamespaceSelector{Include: []NonEmptyString{"team-*"}}
+
+	tests := map[string]struct {
+		opts []MatchOption
+		want []string
+	}{
+		"terminating namespaces are included by default": {
+			opts: []MatchOption{},
+			want: []string{"team-a", "team-b", "team-c", "team-d"},
+		},
+		"terminating namespaces can be excluded": {
+			opts: []MatchOption{ExcludeTerminatingNamespaces()},
+			want: []string{"team-a", "team-b", "team-c"},
+		},
+	}
+
+	for name, tcase := range tests {
+		namespaces, err := sel.GetNamespaceObjects(context.TODO(), fakeClient, tcase.opts...)
+		if err != nil {
+			t.Errorf("Unexpected error '%v' from GetNamespaceObjects, in test '%v'", err, name)
+		}
+
+		got := make([]string, len(namespaces))
+		for i, ns := range namespaces {
+			got[i] = ns.Name
+
+			if ns.Name == "team-a" && ns.Labels["team"] != "a" {
+				t.Errorf("Expected the full namespace object in test '%v', got %+v", name, ns)
+			}
+		}
+
+		if diff := cmp.Diff(tcase.want, got); diff != "" {
+			t.Errorf("Mismatch from GetNamespaceObjects in test '%v': %v", name, diff)
+		}
+
+		names, err := sel.GetNamespaces(context.TODO(), fakeClient, tcase.opts...)
+		if err != nil {
+			t.Errorf("Unexpected error '%v' from GetNamespaces, in test '%v'", err, name)
+		}
+
+		if diff := cmp.Diff(tcase.want, names); diff != "" {
+			t.Errorf("Mismatch from GetNamespaces in test '%v': %v", name, diff)
+		}
+
+		matched, err := sel.Matches(terminating, tcase.opts...)
+		if err != nil {
+			t.Errorf("Unexpected error '%v' from Matches, in test '%v'", err, name)
+		}
+
+		if wantMatch := len(tcase.opts) == 0; matched != wantMatch {
+			t.Errorf("Expected Matches to return %v for the terminating namespace in test '%v'", wantMatch, name)
+		}
+
+		explanations, err := sel.ExplainNamespaces(context.TODO(), fakeClient, tcase.opts...)
+		if err != nil {
+			t.Errorf("Unexpected error '%v' from ExplainNamespaces, in test '%v'", err, name)
+		}
+
+		for _, e := range explanations {
+			if e.Object.GetName() == "team-d" && e.Included != (len(tcase.opts) == 0) {
+				t.Errorf("Unexpected explanation for the terminating namespace in test '%v': %v", name, e)
+			}
+		}
+	}
+
+	target := Target{NamespaceSelector: &sel, Include: []NonEmptyString{"app"}}
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-d"}}
+
+	matched, err := target.Matches(cm, terminating, ExcludeTerminatingNamespaces())
+	if err != nil || matched {
+		t.Errorf("Expected the Target to not match in a terminating namespace, got %v, '%v'", matched, err)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// FieldRequirement is a requirement on the value of a field in an object, similar to a requirement
+// in a LabelSelector, for example: 'spec.type In [LoadBalancer, NodePort]'.
+type FieldRequirement struct {
+	// Path is the location of the field in the object, as keys separated by dots, for example
+	// 'spec.type' or 'metadata.annotations.owner'. Fields inside of lists can not be selected.
+	//+kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+
+	// Operator represents the field's relationship to the set of Values. Accepted values include:
+	// In, NotIn, Exists, and DoesNotExist.
+	Operator FieldOperator `json:"operator"`
+
+	// Values is a list of string values. If the Operator is In or NotIn, the list must be
+	// non-empty. If the Operator is Exists or DoesNotExist, the list must be empty. Numbers and
+	// booleans in the object are compared by their string representation, for example: '3' or
+	// 'true'.
+	Values []string `json:"values,omitempty"`
+}
+
+//+kubebuilder:validation:Enum=In;NotIn;Exists;DoesNotExist
+
+type FieldOperator string
+
+const (
+	// FieldOpIn requires the field to exist, and to be equal to one of the Values.
+	FieldOpIn FieldOperator = "In"
+
+	// FieldOpNotIn requires the field to not be equal to any of the Values. It is satisfied when
+	// the field does not exist.
+	FieldOpNotIn FieldOperator = "NotIn"
+
+	// FieldOpExists requires the field to exist, with any value.
+	FieldOpExists FieldOperator = "Exists"
+
+	// FieldOpDoesNotExist requires the field to not exist.
+	FieldOpDoesNotExist FieldOperator = "DoesNotExist"
+)
+
+// ErrInvalidFieldRequirement is returned when a FieldRequirement's Path is malformed, or when its
+// Values do not agree with its Operator.
+var ErrInvalidFieldRequirement = errors.New("invalid field requirement")
+
+// String returns the requirement in a human-readable form, for example:
+// 'spec.type In [LoadBalancer NodePort]', or 'metadata.labels.app Exists'.
+func (req FieldRequirement) String() string {
+	if len(req.Values) == 0 {
+		return req.Path + " " + string(req.Operator)
+	}
+
+	return fmt.Sprintf("%s %s %v", req.Path, req.Operator, req.Values)
+}
+
+// compiledFieldRequirement is a FieldRequirement whose path has been split into keys.
+type compiledFieldRequirement struct {
+	requirement FieldRequirement
+	keys        []string
+}
+
+// compileFieldRequirements checks each of the requirements, returning them in a form which is
+// faster to evaluate. The problems with the first invalid requirement are returned as an error.
+func compileFieldRequirements(reqs []FieldRequirement) ([]compiledFieldRequirement, error) {
+	compiled := make([]compiledFieldRequirement, len(reqs))
+
+	for i, req := range reqs {
+		errs := req.Validate(field.NewPath("fieldRequirements").Index(i))
+		if len(errs) != 0 {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFieldRequirement, errs.ToAggregate())
+		}
+
+		compiled[i] = compiledFieldRequirement{requirement: req, keys: strings.Split(req.Path, ".")}
+	}
+
+	return compiled, nil
+}
+
+// matches returns whether the unstructured content of an object satisfies the requirement. Fields
+// which are maps or lists are never equal to any of the Values.
+func (req compiledFieldRequirement) matches(content map[string]interface{}) bool {
+	// An error means that a key along the path was not a map, so the field can't exist.
+	val, found, err := unstructured.NestedFieldNoCopy(content, req.keys...)
+	found = found && err == nil
+
+	switch req.requirement.Operator {
+	case FieldOpExists:
+		return found
+	case FieldOpDoesNotExist:
+		return !found
+	case FieldOpIn, FieldOpNotIn:
+		str, isScalar := scalarString(val)
+		in := found && isScalar && slices.Contains(req.requirement.Values, str)
+
+		return in == (req.requirement.Operator == FieldOpIn)
+	}
+
+	return false
+}
+
+// scalarString returns the string representation of the value, if it is a string, number, or
+// boolean.
+func scalarString(val interface{}) (string, bool) {
+	switch v := val.(type) {
+	case string:
+		return v, true
+	case bool, int64, float64:
+		return fmt.Sprint(v), true
+	default:
+		return "", false
+	}
+}
+
+// firstUnmetRequirement returns the first requirement which the unstructured content does not
+// satisfy, or nil if all of the requirements are satisfied.
+func firstUnmetRequirement(
+	reqs []compiledFieldRequirement, content map[string]interface{},
+) *compiledFieldRequirement {
+	for i := range reqs {
+		if !reqs[i].matches(content) {
+			return &reqs[i]
+		}
+	}
+
+	return nil
+}
+
+// objectContent returns the unstructured content of the object, converting it if necessary.
+func objectContent(obj client.Object) (map[string]interface{}, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u.Object, nil
+	}
+
+	return runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+}
@@ -0,0 +1,55 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import "testing"
+
+func TestFieldRequirementScalars(t *testing.T) {
+	t.Parallel()
+
+	content := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"paused":   false,
+			"ratio":    0.5,
+			"ports":    []interface{}{int64(80)},
+		},
+	}
+
+	tests := map[string]struct {
+		req  FieldRequirement
+		want bool
+	}{
+		"integer": {
+			req:  FieldRequirement{Path: "spec.replicas", Operator: FieldOpIn, Values: []string{"1", "3"}},
+			want: true,
+		},
+		"boolean": {
+			req:  FieldRequirement{Path: "spec.paused", Operator: FieldOpIn, Values: []string{"false"}},
+			want: true,
+		},
+		"float": {
+			req:  FieldRequirement{Path: "spec.ratio", Operator: FieldOpNotIn, Values: []string{"0.5"}},
+			want: false,
+		},
+		"list": {
+			req:  FieldRequirement{Path: "spec.ports", Operator: FieldOpIn, Values: []string{"80", "[80]"}},
+			want: false,
+		},
+		"list exists": {
+			req:  FieldRequirement{Path: "spec.ports", Operator: FieldOpExists},
+			want: true,
+		},
+	}
+
+	for name, tcase := range tests {
+		compiled, err := compileFieldRequirements([]FieldRequirement{tcase.req})
+		if err != nil {
+			t.Fatalf("Unexpected error '%v', in test '%v'", err, name)
+		}
+
+		if got := compiled[0].matches(content); got != tcase.want {
+			t.Errorf("Expected %v in test '%v', got %v", tcase.want, name, got)
+		}
+	}
+}
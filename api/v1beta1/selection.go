@@ -0,0 +1,141 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+)
+
+// MaxRecordedNamespaces is the maximum number of names stored in the SelectedNamespaces status.
+const MaxRecordedNamespaces = 50
+
+// NewSelectedNamespaces summarizes the given namespaces (for example, from `GetNamespaces`) for the
+// status of a policy. The names are sorted and de-duplicated, and at most MaxRecordedNamespaces of
+// them are kept; the Count and Hash always describe the full set.
+func NewSelectedNamespaces(namespaces []string) SelectedNamespaces {
+	sorted := sortedUnique(namespaces)
+
+	recorded := sorted
+	if len(recorded) > MaxRecordedNamespaces {
+		recorded = recorded[:MaxRecordedNamespaces]
+	}
+
+	return SelectedNamespaces{
+		Count:      int32(len(sorted)), //nolint:gosec // there are far fewer namespaces than that
+		Namespaces: slices.Clone(recorded),
+		Hash:       hashNamespaces(sorted),
+	}
+}
+
+// Truncated returns whether some of the selected namespaces are not in the list of Namespaces.
+func (sel SelectedNamespaces) Truncated() bool {
+	return int(sel.Count) > len(sel.Namespaces)
+}
+
+// SetSelectedNamespaces records the given namespaces in the status, as described in
+// `NewSelectedNamespaces`. Returns true if the recorded selection changed.
+func (status *PolicyCoreStatus) SetSelectedNamespaces(namespaces []string) (changed bool) {
+	newSel := NewSelectedNamespaces(namespaces)
+
+	if status.SelectedNamespaces != nil && status.SelectedNamespaces.Hash == newSel.Hash {
+		return false
+	}
+
+	status.SelectedNamespaces = &newSel
+
+	return true
+}
+
+// NamespaceSelectionDrift describes how the namespaces selected by a policy differ from the ones
+// recorded in its status.
+type NamespaceSelectionDrift struct {
+	// Added are the namespaces which are selected now, but were not recorded.
+	Added []string
+
+	// Removed are the namespaces which were recorded, but are not selected now.
+	Removed []string
+
+	// Partial is true when the recorded list was truncated, so the namespaces which sort after the
+	// last recorded one could not be compared, and are not in Added or Removed. Changed still
+	// reports whether there were differences there.
+	Partial bool
+
+	// Changed is true when the selection is different from the recorded one.
+	Changed bool
+}
+
+// NamespaceSelectionDrift compares the given namespaces (for example, from `GetNamespaces`) with the
+// ones recorded in the status, and reports which were added and removed. When nothing has been
+// recorded yet, all of the namespaces are reported as added.
+func (status PolicyCoreStatus) NamespaceSelectionDrift(namespaces []string) NamespaceSelectionDrift {
+	current := sortedUnique(namespaces)
+	recorded := SelectedNamespaces{}
+
+	if status.SelectedNamespaces != nil {
+		recorded = *status.SelectedNamespaces
+	}
+
+	drift := NamespaceSelectionDrift{
+		Added:   []string{},
+		Removed: []string{},
+		Partial: recorded.Truncated(),
+		Changed: status.SelectedNamespaces == nil || recorded.Hash != hashNamespaces(current),
+	}
+
+	if !drift.Changed {
+		return drift
+	}
+
+	wasRecorded := make(map[string]bool, len(recorded.Namespaces))
+	for _, ns := range recorded.Namespaces {
+		wasRecorded[ns] = true
+	}
+
+	isCurrent := make(map[string]bool, len(current))
+	for _, ns := range current {
+		isCurrent[ns] = true
+	}
+
+	// The recorded list holds every selected namespace up to its last entry, but nothing after. An
+	// empty list (for example, from an edited status) covers nothing.
+	lastRecorded := ""
+	if len(recorded.Namespaces) > 0 {
+		lastRecorded = recorded.Namespaces[len(recorded.Namespaces)-1]
+	}
+
+	for _, ns := range current {
+		if drift.Partial && (lastRecorded == "" || ns > lastRecorded) {
+			break
+		}
+
+		if !wasRecorded[ns] {
+			drift.Added = append(drift.Added, ns)
+		}
+	}
+
+	for _, ns := range recorded.Namespaces {
+		if !isCurrent[ns] {
+			drift.Removed = append(drift.Removed, ns)
+		}
+	}
+
+	return drift
+}
+
+// sortedUnique returns a sorted copy of the names, without duplicates.
+func sortedUnique(names []string) []string {
+	sorted := slices.Clone(names)
+	slices.Sort(sorted)
+
+	return slices.Compact(sorted)
+}
+
+// hashNamespaces returns a hex-encoded SHA-256 hash of the sorted namespace names.
+func hashNamespaces(sorted []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(sorted, "\n")))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,190 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// numberedNamespaces returns the names "ns-000" through "ns-<count-1>", in order.
+func numberedNamespaces(count int) []string {
+	names := make([]string, count)
+	for i := range names {
+		names[i] = fmt.Sprintf("ns-%03d", i)
+	}
+
+	return names
+}
+
+func TestNewSelectedNamespaces(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input         []string
+		wantCount     int32
+		wantNames     []string
+		wantTruncated bool
+	}{
+		"empty": {
+			input:     []string{},
+			wantCount: 0,
+			wantNames: []string{},
+		},
+		"sorted and de-duplicated": {
+			input:     []string{"team-b", "default", "team-a", "default"},
+			wantCount: 3,
+			wantNames: []string{"default", "team-a", "team-b"},
+		},
+		"truncated": {
+			input:         numberedNamespaces(MaxRecordedNamespaces + 5),
+			wantCount:     MaxRecordedNamespaces + 5,
+			wantNames:     numberedNamespaces(MaxRecordedNamespaces),
+			wantTruncated: true,
+		},
+	}
+
+	for name, tcase := range tests {
+		got := NewSelectedNamespaces(tcase.input)
+
+		if got.Count != tcase.wantCount {
+			t.Errorf("Expected count %v in test '%v', got %v", tcase.wantCount, name, got.Count)
+		}
+
+		if diff := cmp.Diff(tcase.wantNames, got.Namespaces); diff != "" {
+			t.Errorf("Mismatch in the namespaces in test '%v': %v", name, diff)
+		}
+
+		if got.Truncated() != tcase.wantTruncated {
+			t.Errorf("Expected Truncated to be %v in test '%v'", tcase.wantTruncated, name)
+		}
+
+		if got.Hash == "" {
+			t.Errorf("Expected a hash in test '%v'", name)
+		}
+	}
+}
+
+func TestSetSelectedNamespaces(t *testing.T) {
+	t.Parallel()
+
+	status := PolicyCoreStatus{}
+
+	if !status.SetSelectedNamespaces([]string{"team-a", "default"}) {
+		t.Errorf("Expected the first recorded selection to be a change")
+	}
+
+	if status.SetSelectedNamespaces([]string{"default", "team-a"}) {
+		t.Errorf("Expected the same selection in a different order to not be a change")
+	}
+
+	if !status.SetSelectedNamespaces([]string{"default"}) {
+		t.Errorf("Expected a different selection to be a change")
+	}
+
+	if diff := cmp.Diff([]string{"default"}, status.SelectedNamespaces.Namespaces); diff != "" {
+		t.Errorf("Mismatch in the recorded namespaces: %v", diff)
+	}
+}
+
+func TestNamespaceSelectionDrift(t *testing.T) {
+	t.Parallel()
+
+	many := numberedNamespaces(MaxRecordedNamespaces + 5)
+
+	tests := map[string]struct {
+		recorded    []string
+		stored      *SelectedNamespaces
+		current     []string
+		wantAdded   []string
+		wantRemoved []string
+		wantPartial bool
+		wantChanged bool
+	}{
+		"nothing recorded": {
+			recorded:    nil,
+			current:     []string{"team-a", "default"},
+			wantAdded:   []string{"default", "team-a"},
+			wantRemoved: []string{},
+			wantChanged: true,
+		},
+		"no drift": {
+			recorded:    []string{"team-a", "default"},
+			current:     []string{"default", "team-a"},
+			wantAdded:   []string{},
+			wantRemoved: []string{},
+		},
+		"added and removed": {
+			recorded:    []string{"default", "team-a", "team-b"},
+			current:     []string{"default", "team-b", "team-c"},
+			wantAdded:   []string{"team-c"},
+			wantRemoved: []string{"team-a"},
+			wantChanged: true,
+		},
+		"everything removed": {
+			recorded:    []string{"default", "team-a"},
+			current:     []string{},
+			wantAdded:   []string{},
+			wantRemoved: []string{"default", "team-a"},
+			wantChanged: true,
+		},
+		"truncated without drift": {
+			recorded:    many,
+			current:     many,
+			wantAdded:   []string{},
+			wantRemoved: []string{},
+			wantPartial: true,
+		},
+		"truncated with drift in the recorded range": {
+			recorded:    many,
+			current:     append([]string{"aaa"}, many[1:]...),
+			wantAdded:   []string{"aaa"},
+			wantRemoved: []string{"ns-000"},
+			wantPartial: true,
+			wantChanged: true,
+		},
+		"truncated with drift after the recorded range": {
+			recorded:    many,
+			current:     append(many[:len(many)-1:len(many)-1], "zzz"),
+			wantAdded:   []string{},
+			wantRemoved: []string{},
+			wantPartial: true,
+			wantChanged: true,
+		},
+		"count without any recorded names": {
+			stored:      &SelectedNamespaces{Count: 3, Hash: "x"},
+			current:     []string{"a"},
+			wantAdded:   []string{},
+			wantRemoved: []string{},
+			wantPartial: true,
+			wantChanged: true,
+		},
+	}
+
+	for name, tcase := range tests {
+		status := PolicyCoreStatus{SelectedNamespaces: tcase.stored}
+		if tcase.recorded != nil {
+			status.SetSelectedNamespaces(tcase.recorded)
+		}
+
+		got := status.NamespaceSelectionDrift(tcase.current)
+
+		if diff := cmp.Diff(tcase.wantAdded, got.Added); diff != "" {
+			t.Errorf("Mismatch in the added namespaces in test '%v': %v", name, diff)
+		}
+
+		if diff := cmp.Diff(tcase.wantRemoved, got.Removed); diff != "" {
+			t.Errorf("Mismatch in the removed namespaces in test '%v': %v", name, diff)
+		}
+
+		if got.Partial != tcase.wantPartial {
+			t.Errorf("Expected Partial to be %v in test '%v'", tcase.wantPartial, name)
+		}
+
+		if got.Changed != tcase.wantChanged {
+			t.Errorf("Expected Changed to be %v in test '%v'", tcase.wantChanged, name)
+		}
+	}
+}
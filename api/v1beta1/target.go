@@ -4,27 +4,118 @@ package v1beta1
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 type Target struct {
 	*metav1.LabelSelector `json:",inline"`
 
+	// AnnotationSelector restricts the Target to objects whose annotations match the selector, with
+	// the same semantics as the LabelSelector. Since annotations can not be filtered by the API
+	// server, this is evaluated on the client after the objects are listed.
+	AnnotationSelector *metav1.LabelSelector `json:"annotationSelector,omitempty"`
+
 	// Namespace is the namespace to restrict the Target to. Can be empty for non-namespaced
 	// objects, or to look in all namespaces.
 	Namespace string `json:"namespace,omitempty"`
 
-	// Include is a list of filepath expressions to include objects by name.
+	// NamespaceSelector restricts the Target to the namespaces matching the selector. Like in the
+	// PolicyCoreSpec, an empty NamespaceSelector will match zero namespaces. If the Namespace is
+	// also set, only that namespace will be used, and only if it matches the selector.
+	NamespaceSelector *NamespaceSelector `json:"namespaceSelector,omitempty"`
+
+	// Include is a list of patterns to include objects by name. By default, these are filepath
+	// expressions; this can be changed with the MatchMode.
 	Include []NonEmptyString `json:"include,omitempty"`
 
-	// Exclude is a list of filepath expressions to include objects by name.
+	// Exclude is a list of patterns to exclude objects by name. By default, these are filepath
+	// expressions; this can be changed with the MatchMode.
 	Exclude []NonEmptyString `json:"exclude,omitempty"`
+
+	// MatchMode determines how the Include and Exclude patterns are interpreted. Accepted values
+	// include: Glob (the default), and Regex.
+	MatchMode MatchMode `json:"matchMode,omitempty"`
+
+	// FieldSelector restricts the Target to objects with matching field values, for example
+	// 'status.phase=Running'. The syntax is the same as for `kubectl get --field-selector`. When the
+	// API server (or cache) does not support filtering on a field, the objects are filtered on the
+	// client instead.
+	FieldSelector string `json:"fieldSelector,omitempty"`
+
+	// Owner restricts the Target to objects with an owner reference matching the selector. This is
+	// evaluated on the client, after the Include and Exclude patterns.
+	Owner *OwnerSelector `json:"owner,omitempty"`
+
+	// NoOwner restricts the Target to objects which do not have any owner references. It can not be
+	// used at the same time as the Owner.
+	NoOwner bool `json:"noOwner,omitempty"`
+
+	// OlderThan restricts the Target to objects which were created more than this long ago, for
+	// example '2160h' for 90 days. This is evaluated on the client, after the Include and Exclude
+	// patterns.
+	OlderThan *metav1.Duration `json:"olderThan,omitempty"`
+
+	// NewerThan restricts the Target to objects which were created less than this long ago, for
+	// example '5m'. If the OlderThan is also set, this must be longer than it, and only objects
+	// created between the two times will be matched.
+	NewerThan *metav1.Duration `json:"newerThan,omitempty"`
+
+	// FieldRequirements restricts the Target to objects whose fields satisfy all of the
+	// requirements, for example 'spec.type In [LoadBalancer, NodePort]'. Unlike the FieldSelector,
+	// these are always evaluated on the client, after the Include and Exclude patterns, so any
+	// field in the object can be used.
+	FieldRequirements []FieldRequirement `json:"fieldRequirements,omitempty"`
+
+	// Expression is a CEL expression which further restricts the Target to the objects it
+	// evaluates to true for. The object being evaluated is available as the `object` variable, in
+	// its unstructured form, for example: 'object.data.enabled == "true"'. It is evaluated after
+	// all the other filters, and an evaluation which fails (for example, when accessing a field
+	// that does not exist, or when it is too expensive) will cause the whole match to fail; use
+	// `has()` to check for optional fields.
+	Expression string `json:"expression,omitempty"`
+}
+
+//+kubebuilder:validation:Enum=Glob;Regex
+
+type MatchMode string
+
+const (
+	// GlobMatchMode interprets the patterns as filepath expressions, as in `filepath.Match`. This
+	// is the default when the MatchMode is empty.
+	GlobMatchMode MatchMode = "Glob"
+
+	// RegexMatchMode interprets the patterns as regular expressions, using the syntax of the
+	// `regexp` package. The expressions are anchored, so they must match the entire name: for
+	// example, use '.*-(prod|stage)' to match names ending in '-prod' or '-stage'.
+	RegexMatchMode MatchMode = "Regex"
+)
+
+// ErrUnknownMatchMode is returned when a pattern is evaluated with a MatchMode that is not
+// recognized.
+var ErrUnknownMatchMode = errors.New("unknown match mode")
+
+// compileAnchoredRegex compiles the pattern so that it must match the entire name. The pattern is
+// first compiled on its own, so that any syntax errors refer to the pattern as it was written.
+func compileAnchoredRegex(pattern string) (*regexp.Regexp, error) {
+	if _, err := regexp.Compile(pattern); err != nil {
+		return nil, err
+	}
+
+	return regexp.Compile("^(?:" + pattern + ")$")
 }
 
 //+kubebuilder:object:generate=false
@@ -61,38 +152,126 @@ type ResourceList interface {
 // ResourceList should be backed by a client.ObjectList type which must registered in the scheme of
 // the client.Reader. The items in the provided ResourceList after this method is called will not
 // necessarily equal the items matched by the Target. The items returned here will be in relatively
-// the same order as they were in the list returned by the API.
+// the same order as they were in the list returned by the API. When the Target has a
+// NamespaceSelector, the matches in each selected namespace are combined, in alphabetical order of
+// the namespaces, with any duplicates removed.
 //
 // This method should be used preferentially to `GetMatchesDynamic` because it can leverage the
-// Reader's cache.
+// Reader's cache. Note that a cached Reader can only use a FieldSelector when an index has been
+// registered for each field: otherwise, the objects will be filtered on the client side, and a
+// message will be logged about the fallback. Optional behavior, like listing the objects in chunks,
+// can be configured with MatchOptions.
 //
 // NOTE: unlike the NamespaceSelector, an empty Target will match *all* resources on the cluster.
-func (t Target) GetMatches(ctx context.Context, r client.Reader, list ResourceList) ([]client.Object, error) {
-	nonNilSel := t.LabelSelector
-	if nonNilSel == nil { // override it to be empty if it is nil
-		nonNilSel = &metav1.LabelSelector{}
-	}
-
-	labelSel, err := metav1.LabelSelectorAsSelector(nonNilSel)
+func (t Target) GetMatches(
+	ctx context.Context, r client.Reader, list ResourceList, opts ...MatchOption,
+) ([]client.Object, error) {
+	compiled, err := t.Compile()
 	if err != nil {
 		return nil, err
 	}
 
-	listOpts := client.ListOptions{
-		LabelSelector: labelSel,
-		Namespace:     t.Namespace,
+	return compiled.GetMatches(ctx, r, list, opts...)
+}
+
+// GetMatches returns a list of resources on the cluster, matched by the compiled Target. See
+// `Target.GetMatches` for more details.
+func (ct *CompiledTarget) GetMatches(
+	ctx context.Context, r client.Reader, list ResourceList, opts ...MatchOption,
+) ([]client.Object, error) {
+	matches, _, err := ct.getMatches(ctx, r, list, opts)
+
+	return matches, err
+}
+
+// getMatches implements `GetMatches`, and also returns whether the matches are already copies,
+// which are not affected when the ResourceList is re-used.
+func (ct *CompiledTarget) getMatches(
+	ctx context.Context, r client.Reader, list ResourceList, opts []MatchOption,
+) (matches []client.Object, copied bool, err error) {
+	options := newMatchOptions(opts)
+
+	if ct.namespaceSelector != nil {
+		nsReader := options.namespaceReader
+		if nsReader == nil {
+			nsReader = r
+		}
+
+		matches, err := matchesInSelectedNamespaces(ctx, ct, nsReader, opts,
+			func(nsTarget *CompiledTarget) ([]client.Object, error) {
+				nsMatches, nsCopied, err := nsTarget.getMatches(ctx, r, list, opts)
+				if err == nil && !nsCopied {
+					copyObjects(nsMatches)
+				}
+
+				return nsMatches, err
+			},
+		)
+
+		return matches, true, err
 	}
 
-	if err := r.List(ctx, list.ObjectList(), &listOpts); err != nil {
-		return nil, err
+	now := options.clock.Now()
+
+	listPage := func(continueToken string, limit int64, useFieldSel bool) ([]client.Object, string, error) {
+		listOpts := client.ListOptions{
+			LabelSelector: ct.labelSel,
+			Namespace:     ct.target.Namespace,
+			Limit:         limit,
+			Continue:      continueToken,
+		}
+
+		if useFieldSel {
+			listOpts.FieldSelector = ct.fieldSel
+		}
+
+		if err := r.List(ctx, list.ObjectList(), &listOpts); err != nil {
+			return nil, "", err
+		}
+
+		items, err := list.Items()
+		if err != nil {
+			return nil, "", err
+		}
+
+		return items, list.ObjectList().GetContinue(), nil
 	}
 
-	items, err := list.Items()
-	if err != nil {
-		return nil, err
+	filterPage := func(items []client.Object, filterFields bool) ([]client.Object, error) {
+		matches := make([]client.Object, 0, len(items))
+
+		for _, item := range items {
+			explanation, err := ct.filterObject(item, filterFields, now)
+			if err != nil {
+				return nil, err
+			}
+
+			if explanation.Included {
+				matches = append(matches, item)
+			}
+		}
+
+		if options.pageSize > 0 {
+			copyObjects(matches)
+		}
+
+		return matches, nil
 	}
 
-	return t.matchesByName(items)
+	matches, err = listMatchesInPages(ctx, ct.target.FieldSelector, options.pageSize, listPage, filterPage)
+
+	return matches, options.pageSize > 0, err
+}
+
+// copyObjects replaces each object with a deep copy, in place. This is needed before a ResourceList
+// is re-used for another request (for the next page, namespace, or Target), since decoding into the
+// list might overwrite the objects it held before.
+func copyObjects(objs []client.Object) {
+	for i, obj := range objs {
+		if copied, ok := obj.DeepCopyObject().(client.Object); ok {
+			objs[i] = copied
+		}
+	}
 }
 
 // GetMatchesDynamic returns a list of resources on the cluster, matched by the Target. The kind of
@@ -100,98 +279,473 @@ func (t Target) GetMatches(ctx context.Context, r client.Reader, list ResourceLi
 // namespace, this method will limit the namespace of the provided Interface if possible. If the
 // provided Interface is already namespaced, the namespace of the Interface will be used (possibly
 // overriding the namespace specified in the Target). The items returned here will be in relatively
-// the same order as they were in the list returned by the API.
+// the same order as they were in the list returned by the API (combined in alphabetical order of
+// the namespaces, when the Target has a NamespaceSelector). If the API server rejects the
+// FieldSelector, the objects will be filtered on the client side, and a message will be logged.
+// Optional behavior, like listing the objects in chunks, can be configured with MatchOptions.
+//
+// When the Target has a NamespaceSelector, a client.Reader for the namespaces must be provided
+// with the WithNamespaceReader option, otherwise an ErrNoNamespaceReader is returned.
 //
 // NOTE: unlike the NamespaceSelector, an empty Target will match *all* resources on the cluster.
 func (t Target) GetMatchesDynamic(
-	ctx context.Context, iface dynamic.ResourceInterface,
+	ctx context.Context, iface dynamic.ResourceInterface, opts ...MatchOption,
 ) ([]*unstructured.Unstructured, error) {
-	labelSel, err := metav1.LabelSelectorAsSelector(t.LabelSelector)
+	compiled, err := t.Compile()
 	if err != nil {
 		return nil, err
 	}
 
-	if t.Namespace != "" {
+	return compiled.GetMatchesDynamic(ctx, iface, opts...)
+}
+
+// GetMatchesDynamic returns a list of resources on the cluster, matched by the compiled Target.
+// See `Target.GetMatchesDynamic` for more details.
+func (ct *CompiledTarget) GetMatchesDynamic(
+	ctx context.Context, iface dynamic.ResourceInterface, opts ...MatchOption,
+) ([]*unstructured.Unstructured, error) {
+	options := newMatchOptions(opts)
+
+	if ct.namespaceSelector != nil {
+		if options.namespaceReader == nil {
+			return nil, ErrNoNamespaceReader
+		}
+
+		return matchesInSelectedNamespaces(ctx, ct, options.namespaceReader, opts,
+			func(nsTarget *CompiledTarget) ([]*unstructured.Unstructured, error) {
+				return nsTarget.GetMatchesDynamic(ctx, iface, opts...)
+			},
+		)
+	}
+
+	if ct.target.Namespace != "" {
 		if namespaceableIface, ok := iface.(dynamic.NamespaceableResourceInterface); ok {
-			iface = namespaceableIface.Namespace(t.Namespace)
+			iface = namespaceableIface.Namespace(ct.target.Namespace)
 		}
 	}
 
-	objs, err := iface.List(ctx, metav1.ListOptions{LabelSelector: labelSel.String()})
+	now := options.clock.Now()
+
+	listPage := func(
+		continueToken string, limit int64, useFieldSel bool,
+	) ([]*unstructured.Unstructured, string, error) {
+		listOpts := metav1.ListOptions{
+			LabelSelector: ct.labelSel.String(),
+			Limit:         limit,
+			Continue:      continueToken,
+		}
+
+		if useFieldSel {
+			listOpts.FieldSelector = ct.fieldSel.String()
+		}
+
+		objs, err := iface.List(ctx, listOpts)
+		if err != nil {
+			return nil, "", err
+		}
+
+		items := make([]*unstructured.Unstructured, len(objs.Items))
+		for i := range objs.Items {
+			items[i] = &objs.Items[i]
+		}
+
+		return items, objs.GetContinue(), nil
+	}
+
+	filterPage := func(items []*unstructured.Unstructured, filterFields bool) ([]*unstructured.Unstructured, error) {
+		matchedObjs := make([]*unstructured.Unstructured, 0)
+
+		for _, obj := range items {
+			explanation, err := ct.filterObject(obj, filterFields, now)
+			if err != nil {
+				return nil, err
+			}
+
+			if explanation.Included {
+				// Copy the match out of the page, so that the rest of the page can be freed.
+				match := *obj
+				matchedObjs = append(matchedObjs, &match)
+			}
+		}
+
+		return matchedObjs, nil
+	}
+
+	return listMatchesInPages(ctx, ct.target.FieldSelector, options.pageSize, listPage, filterPage)
+}
+
+// GetMatchesMetadata returns the metadata of the resources on the cluster, matched by the Target.
+// The kind of the resources is configured by the provided GroupVersionKind, and the objects are
+// listed as a PartialObjectMetadataList, so that the bodies of the objects are never retrieved. This
+// can save a lot of memory and bandwidth for large resources like Secrets and ConfigMaps, and can
+// use a controller-runtime metadata cache when the Reader has one. The returned items will have
+// the provided GroupVersionKind set.
+//
+// The matching behaves like `GetMatches`, except that only the object metadata is available when
+// a FieldSelector is evaluated on the client side: any other fields will be treated as empty.
+// Similarly, the FieldRequirements and the Expression can only use the `apiVersion`, `kind`, and
+// `metadata` of the object.
+//
+// NOTE: unlike the NamespaceSelector, an empty Target will match *all* resources on the cluster.
+func (t Target) GetMatchesMetadata(
+	ctx context.Context, r client.Reader, gvk schema.GroupVersionKind, opts ...MatchOption,
+) ([]*metav1.PartialObjectMetadata, error) {
+	compiled, err := t.Compile()
 	if err != nil {
 		return nil, err
 	}
 
-	matchedObjs := make([]*unstructured.Unstructured, 0)
+	return compiled.GetMatchesMetadata(ctx, r, gvk, opts...)
+}
 
-	for _, obj := range objs.Items {
-		obj := obj
+// GetMatchesMetadata returns the metadata of the resources on the cluster, matched by the compiled
+// Target. See `Target.GetMatchesMetadata` for more details.
+func (ct *CompiledTarget) GetMatchesMetadata(
+	ctx context.Context, r client.Reader, gvk schema.GroupVersionKind, opts ...MatchOption,
+) ([]*metav1.PartialObjectMetadata, error) {
+	matches, err := ct.GetMatches(ctx, r, &metadataResList{gvk: gvk}, opts...)
+	if err != nil {
+		return nil, err
+	}
 
-		matched, err := t.match(obj.GetName())
-		if err != nil {
-			return matchedObjs, err
+	metas := make([]*metav1.PartialObjectMetadata, 0, len(matches))
+
+	for _, match := range matches {
+		meta, ok := match.(*metav1.PartialObjectMetadata)
+		if !ok {
+			continue
 		}
 
-		if matched {
-			matchedObjs = append(matchedObjs, &obj)
+		meta.SetGroupVersionKind(gvk)
+
+		metas = append(metas, meta)
+	}
+
+	return metas, nil
+}
+
+type metadataResList struct {
+	metav1.PartialObjectMetadataList
+	gvk schema.GroupVersionKind
+}
+
+// Run a compile-time check to ensure metadataResList implements ResourceList.
+var _ ResourceList = (*metadataResList)(nil)
+
+func (l *metadataResList) Items() ([]client.Object, error) {
+	items := make([]client.Object, len(l.PartialObjectMetadataList.Items))
+	for i := range l.PartialObjectMetadataList.Items {
+		items[i] = &l.PartialObjectMetadataList.Items[i]
+	}
+
+	return items, nil
+}
+
+//nolint:ireturn // the ResourceList interface requires this interface return
+func (l *metadataResList) ObjectList() client.ObjectList {
+	// Some clients replace the whole list, so the kind must be set again before each request.
+	l.SetGroupVersionKind(l.gvk.GroupVersion().WithKind(l.gvk.Kind + "List"))
+
+	return &l.PartialObjectMetadataList
+}
+
+// GetTypedMatches returns a list of resources on the cluster, matched by the Target, as their
+// concrete type. It behaves like `GetMatches`, but instead of a ResourceList, it takes a function
+// returning the items from the list type of the resource. For example, for ConfigMaps:
+//
+//	cms, err := GetTypedMatches(ctx, target, r,
+//		func(l *corev1.ConfigMapList) []corev1.ConfigMap { return l.Items })
+//
+// would return a `[]*corev1.ConfigMap`. The list type must be registered in the scheme of the
+// client.Reader.
+func GetTypedMatches[T any, PT interface {
+	*T
+	client.Object
+}, L any, PL interface {
+	*L
+	client.ObjectList
+}](
+	ctx context.Context, t Target, r client.Reader, items func(PL) []T, opts ...MatchOption,
+) ([]PT, error) {
+	matches, err := t.GetMatches(ctx, r, &typedResList[T, PT, L, PL]{items: items}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	typed := make([]PT, 0, len(matches))
+
+	for _, match := range matches {
+		if obj, ok := match.(PT); ok {
+			typed = append(typed, obj)
 		}
 	}
 
-	return matchedObjs, nil
+	return typed, nil
 }
 
-// matchesByName filters a list of client.Objects by name, and returns ones that
-// match the Include and Exclude lists in the Target. The only possible returned
-// error is a wrapped filepath.ErrBadPattern.
-func (t Target) matchesByName(items []client.Object) ([]client.Object, error) {
-	matches := make([]client.Object, 0)
+// typedResList implements ResourceList for any list type, using a function to get its items.
+type typedResList[T any, PT interface {
+	*T
+	client.Object
+}, L any, PL interface {
+	*L
+	client.ObjectList
+}] struct {
+	list  L
+	items func(PL) []T
+}
 
-	for _, item := range items {
-		matched, err := t.match(item.GetName())
+func (l *typedResList[T, PT, L, PL]) Items() ([]client.Object, error) {
+	items := l.items(&l.list)
+
+	objs := make([]client.Object, len(items))
+	for i := range items {
+		objs[i] = PT(&items[i])
+	}
+
+	return objs, nil
+}
+
+//nolint:ireturn // the ResourceList interface requires this interface return
+func (l *typedResList[T, PT, L, PL]) ObjectList() client.ObjectList {
+	return PL(&l.list)
+}
+
+// ErrNoNamespaceReader is returned when a Target has a NamespaceSelector, but there is no
+// client.Reader available to find the selected namespaces.
+var ErrNoNamespaceReader = errors.New("a namespace reader is required to use the NamespaceSelector")
+
+// matchesInSelectedNamespaces calls the getMatches function with a copy of the compiled Target
+// restricted to each namespace selected by its NamespaceSelector, in alphabetical order. The
+// matches are combined, with any duplicates (by namespace and name) removed, keeping the first
+// occurrence.
+func matchesInSelectedNamespaces[T client.Object](
+	ctx context.Context,
+	ct *CompiledTarget,
+	nsReader client.Reader,
+	opts []MatchOption,
+	getMatches func(nsTarget *CompiledTarget) ([]T, error),
+) ([]T, error) {
+	namespaces, err := ct.namespaceSelector.GetNamespaces(ctx, nsReader, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Sort(namespaces)
+
+	matches := make([]T, 0)
+	seen := make(map[string]bool)
+
+	for _, ns := range namespaces {
+		if ct.target.Namespace != "" && ct.target.Namespace != ns {
+			continue
+		}
+
+		nsTarget := ct.inNamespace(ns)
+
+		nsMatches, err := getMatches(nsTarget)
 		if err != nil {
 			return nil, err
 		}
 
-		if matched {
-			matches = append(matches, item)
-		}
+		matches = appendUnique(matches, seen, nsMatches)
 	}
 
 	return matches, nil
 }
 
-// match returns whether the given name matches the Include and Exclude lists in
-// the Target.
-func (t Target) match(name string) (bool, error) {
-	var err error
+// appendUnique appends the items to the matches, skipping any whose namespace and name are already
+// in the seen map, which is updated with the new items.
+func appendUnique[T client.Object](matches []T, seen map[string]bool, items []T) []T {
+	for _, item := range items {
+		key := item.GetNamespace() + "/" + item.GetName()
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+
+		matches = append(matches, item)
+	}
+
+	return matches
+}
+
+// maxListRestarts is the number of times a paginated list will be restarted from the beginning
+// after its continue token expires, before giving up.
+const maxListRestarts = 3
+
+// listMatchesInPages repeatedly calls the listPage function, following the continue tokens that it
+// returns, and accumulates the items from each page which remain after the filterPage function.
+// If a continue token expires, the listing will be restarted from the beginning (a limited number
+// of times). If the first page could not be listed because the field selector is not supported,
+// it is retried without using it, and the filterPage function will be told to filter the fields on
+// the client side. Other errors are returned unchanged.
+//
+// A controller-runtime cache applies the limit by truncating the list, without a continue token. So
+// when the first page is exactly full but has no continue token, it can not be trusted, and the
+// list is retried without a limit.
+func listMatchesInPages[T any, M any](
+	ctx context.Context,
+	fieldSelector string,
+	pageSize int64,
+	listPage func(continueToken string, limit int64, useFieldSel bool) (items []T, next string, err error),
+	filterPage func(items []T, filterFields bool) ([]M, error),
+) ([]M, error) {
+	matches := make([]M, 0)
+	useFieldSel := fieldSelector != ""
+	continueToken := ""
+	restarts := 0
+
+	for {
+		items, next, err := listPage(continueToken, pageSize, useFieldSel)
+		if err != nil {
+			switch {
+			case continueToken != "" && k8sErrors.IsResourceExpired(err) && restarts < maxListRestarts:
+				log.FromContext(ctx).Info("The continue token expired while listing, restarting the list",
+					"error", err.Error())
+
+				restarts++
+				matches = matches[:0]
+				continueToken = ""
+			case continueToken == "" && useFieldSel && fieldSelectorUnsupported(err):
+				logFieldSelectorFallback(ctx, fieldSelector, err)
+
+				useFieldSel = false
+			default:
+				return nil, err
+			}
+
+			continue
+		}
+
+		if continueToken == "" && pageSize > 0 && int64(len(items)) == pageSize && next == "" {
+			log.FromContext(ctx).V(1).Info("The first page of the list might have been truncated without a "+
+				"continue token, as a cache does; listing everything at once instead", "pageSize", pageSize)
 
-	include := len(t.Include) == 0 // include everything if empty/unset
+			pageSize = 0
 
-	for _, includePattern := range t.Include {
-		include, err = filepath.Match(string(includePattern), name)
+			continue
+		}
+
+		pageMatches, err := filterPage(items, fieldSelector != "" && !useFieldSel)
 		if err != nil {
-			return false, fmt.Errorf("error parsing 'include' pattern '%s': %w", string(includePattern), err)
+			return nil, err
 		}
 
-		if include {
-			break
+		matches = append(matches, pageMatches...)
+
+		if next == "" {
+			return matches, nil
 		}
+
+		continueToken = next
 	}
+}
 
-	if !include {
-		return false, nil
+// parseFieldSelector returns the parsed FieldSelector of the Target, or nil if it is not set.
+//
+//nolint:ireturn // fields.ParseSelector only provides the fields.Selector interface
+func (t Target) parseFieldSelector() (fields.Selector, error) {
+	if t.FieldSelector == "" {
+		return nil, nil //nolint:nilnil // a nil selector is meaningful for the client.ListOptions
+	}
+
+	return fields.ParseSelector(t.FieldSelector)
+}
+
+// fieldSelectorUnsupported returns whether the error from a list means that the field selector can
+// not be used for the kind: either the API server rejected it as a bad request, or the
+// controller-runtime cache (or fake client) has no index for one of its fields, or can not use it
+// because it is not an exact match.
+func fieldSelectorUnsupported(err error) bool {
+	if k8sErrors.IsBadRequest(err) {
+		return true
 	}
 
-	for _, excludePattern := range t.Exclude {
-		exclude, err := filepath.Match(string(excludePattern), name)
+	msg := strings.ToLower(err.Error())
+
+	return strings.Contains(msg, "index with name") ||
+		strings.Contains(msg, "field matches are not supported") ||
+		strings.Contains(msg, "not in one of the two supported forms")
+}
+
+func logFieldSelectorFallback(ctx context.Context, fieldSelector string, err error) {
+	log.FromContext(ctx).Info("Failed to list using the field selector, falling back to filtering on the client",
+		"fieldSelector", fieldSelector, "error", err.Error())
+}
+
+// fieldsMatch returns whether the object matches the given field selector, evaluating the fields
+// on the client side. An error is returned if the object can not be converted into an unstructured
+// form.
+func fieldsMatch(sel fields.Selector, obj client.Object) (bool, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return sel.Matches(unstructuredFieldSet(sel, u.Object)), nil
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return false, err
+	}
+
+	return sel.Matches(unstructuredFieldSet(sel, content)), nil
+}
+
+// unstructuredFieldSet returns the values of the fields used by the selector, from the given
+// unstructured content. Fields which are not found in the content will have an empty value.
+func unstructuredFieldSet(sel fields.Selector, content map[string]interface{}) fields.Set {
+	set := fields.Set{}
+
+	for _, req := range sel.Requirements() {
+		val, found, err := unstructured.NestedFieldNoCopy(content, strings.Split(req.Field, ".")...)
+		if err != nil || !found || val == nil {
+			set[req.Field] = ""
+
+			continue
+		}
+
+		if str, ok := val.(string); ok {
+			set[req.Field] = str
+		} else {
+			set[req.Field] = fmt.Sprint(val)
+		}
+	}
+
+	return set
+}
+
+// Matches returns whether the Target matches the given object, without any API calls. The result is
+// the same as whether the object would be returned by GetMatches. When the Target has a
+// NamespaceSelector, the object's namespace must be provided, so that the selector can be evaluated
+// against its labels; if it is nil (or a different namespace), the object is not matched. Otherwise
+// the namespace is ignored, and it can be nil. Errors are returned for malformed selectors or
+// patterns, like they would be from GetMatches.
+func (t Target) Matches(obj client.Object, namespace client.Object, opts ...MatchOption) (bool, error) {
+	compiled, err := t.Compile()
+	if err != nil {
+		return false, err
+	}
+
+	return compiled.Matches(obj, namespace, opts...)
+}
+
+// Matches returns whether the compiled Target matches the given object, without any API calls. See
+// `Target.Matches` for more details.
+func (ct *CompiledTarget) Matches(obj client.Object, namespace client.Object, opts ...MatchOption) (bool, error) {
+	var selectedNamespaces []string
+
+	if ct.namespaceSelector != nil && namespace != nil && namespace.GetName() == obj.GetNamespace() {
+		selected, err := ct.namespaceSelector.Matches(namespace, opts...)
 		if err != nil {
-			return false, fmt.Errorf("error parsing 'exclude' pattern '%s': %w", string(excludePattern), err)
+			return false, err
 		}
 
-		if exclude {
-			return false, nil
+		if selected {
+			selectedNamespaces = []string{namespace.GetName()}
 		}
 	}
 
-	return true, nil
+	explanation, err := ct.ExplainObject(obj, selectedNamespaces, opts...)
+
+	return explanation.Included, err
 }
@@ -3,26 +3,47 @@
 package v1beta1
 
 import (
+	"context"
 	"errors"
 	"path/filepath"
+	"regexp/syntax"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+	dynfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	clienttesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 )
 
 var sampleNames = []string{
 	"foo", "bar", "baz", "boo", "default", "kube-one", "kube-two", "kube-three",
 }
 
-// matches is only used to unit-test the behavior of `match`.
+// matches is only used to unit-test the behavior of the Target's Include and Exclude patterns.
 func (t *Target) matches(names []string) ([]string, error) {
 	// Using a map to ensure each entry in the result is unique.
 	set := make(map[string]struct{})
+	matcher := newNameMatcher(t.MatchMode, t.Include, t.Exclude)
 
 	for _, name := range names {
-		matched, err := t.match(name)
+		matched, err := matcher.match(name)
 		if err != nil {
 			return nil, err
 		}
@@ -251,3 +272,1077 @@ func TestMatchesErrors(t *testing.T) {
 		}
 	}
 }
+
+func TestMatchesRegex(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		inc  []NonEmptyString
+		exc  []NonEmptyString
+		want []string
+	}{
+		"include with an alternation": {
+			inc:  []NonEmptyString{"kube-(one|two)"},
+			exc:  []NonEmptyString{},
+			want: []string{"kube-one", "kube-two"},
+		},
+		"exclude with an alternation": {
+			inc:  []NonEmptyString{".*"},
+			exc:  []NonEmptyString{"kube-(one|two)"},
+			want: []string{"foo", "bar", "baz", "boo", "default", "kube-three"},
+		},
+		"include names ending in a suffix": {
+			inc:  []NonEmptyString{".*-t.*"},
+			exc:  []NonEmptyString{},
+			want: []string{"kube-two", "kube-three"},
+		},
+		"patterns are anchored": {
+			inc:  []NonEmptyString{"oo", "ba"},
+			exc:  []NonEmptyString{},
+			want: []string{},
+		},
+		"include with a character class and repetition": {
+			inc:  []NonEmptyString{"[a-z]{3}"},
+			exc:  []NonEmptyString{"b.*"},
+			want: []string{"foo"},
+		},
+		"include and exclude are both empty": {
+			inc:  []NonEmptyString{},
+			exc:  []NonEmptyString{},
+			want: sampleNames,
+		},
+	}
+
+	for name, tcase := range tests {
+		sel := Target{Include: tcase.inc, Exclude: tcase.exc, MatchMode: RegexMatchMode}
+
+		got, err := sel.matches(sampleNames)
+		if err != nil {
+			t.Errorf("Unexpected error '%v', in test '%v'", err, name)
+		}
+
+		less := func(a, b string) bool { return a < b }
+		diff := cmp.Diff(tcase.want, got, cmpopts.SortSlices(less), cmpopts.EquateEmpty())
+
+		if diff != "" {
+			t.Errorf("Mismatch in test '%v': %v", name, diff)
+		}
+	}
+}
+
+func TestMatchesRegexErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		inc     []NonEmptyString
+		exc     []NonEmptyString
+		wantMsg string
+	}{
+		"include is malformed": {
+			inc: []NonEmptyString{"kube-(system"},
+			exc: []NonEmptyString{},
+			wantMsg: "error parsing 'include' pattern 'kube-(system': error parsing regexp: missing closing ): " +
+				"`kube-(system`",
+		},
+		"exclude is malformed": {
+			inc:     []NonEmptyString{".*"},
+			exc:     []NonEmptyString{"foo[bar"},
+			wantMsg: "error parsing 'exclude' pattern 'foo[bar': error parsing regexp: missing closing ]: `[bar`",
+		},
+	}
+
+	for name, tcase := range tests {
+		sel := Target{Include: tcase.inc, Exclude: tcase.exc, MatchMode: RegexMatchMode}
+
+		_, err := sel.matches(sampleNames)
+		if err == nil {
+			t.Errorf("Expected an error in test '%v', but got nil", name)
+
+			continue
+		}
+
+		var syntaxErr *syntax.Error
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("Error mismatch in test '%v', got '%v' wanted a *syntax.Error", name, err)
+		}
+
+		if err.Error() != tcase.wantMsg {
+			t.Errorf("Error message mismatch in test '%v', got '%v' wanted '%v'", name, err, tcase.wantMsg)
+		}
+	}
+}
+
+func TestMatchesUnknownMode(t *testing.T) {
+	t.Parallel()
+
+	sel := Target{Include: []NonEmptyString{"*"}, MatchMode: "Fancy"}
+
+	_, err := sel.matches(sampleNames)
+	if !errors.Is(err, ErrUnknownMatchMode) {
+		t.Errorf("Error mismatch, got '%v' wanted ErrUnknownMatchMode", err)
+	}
+}
+
+type configMapResList struct {
+	corev1.ConfigMapList
+}
+
+func (l *configMapResList) Items() ([]client.Object, error) {
+	items := make([]client.Object, len(l.ConfigMapList.Items))
+	for i := range l.ConfigMapList.Items {
+		items[i] = &l.ConfigMapList.Items[i]
+	}
+
+	return items, nil
+}
+
+func (l *configMapResList) ObjectList() client.ObjectList {
+	return &l.ConfigMapList
+}
+
+// sampleConfigMaps returns a ConfigMap for each of the sampleNames in the "default" namespace. The
+// "tier" in the data is "gold" for names starting with "b", and "silver" otherwise.
+func sampleConfigMaps() []runtime.Object {
+	objs := make([]runtime.Object, len(sampleNames))
+
+	for i, name := range sampleNames {
+		tier := "silver"
+		if strings.HasPrefix(name, "b") {
+			tier = "gold"
+		}
+
+		objs[i] = &corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    map[string]string{"sample": name},
+			},
+			Data: map[string]string{"tier": tier},
+		}
+	}
+
+	return objs
+}
+
+var sampleNow = time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+// detailedConfigMaps returns the sampleConfigMaps, with more metadata for the filters which need it:
+//   - Annotations: the names starting with "kube-" have an "exempt" annotation, and every other one
+//     has an "owner" annotation with a URL value.
+//   - Owners: "foo" and "bar" are owned by ReplicaSets of a "web" Deployment, "baz" is owned by a
+//     ReplicaSet of an "api" Deployment and by a Secret, and the rest do not have any owners.
+//   - Creation timestamps, relative to sampleNow: "foo" is 100 days old, "bar" is 10 days old, "baz"
+//     is 1 hour old, "boo" is 1 minute old, and the rest are exactly 90 days old.
+func detailedConfigMaps() []runtime.Object {
+	owners := map[string][]metav1.OwnerReference{
+		"foo": {{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-5d4f8", UID: "uid-web-1"}},
+		"bar": {{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-7c9b2", UID: "uid-web-2"}},
+		"baz": {
+			{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "api-6b3e1", UID: "uid-api-1"},
+			{APIVersion: "v1", Kind: "Secret", Name: "web-token", UID: "uid-secret"},
+		},
+	}
+
+	ages := map[string]time.Duration{
+		"foo": 100 * 24 * time.Hour,
+		"bar": 10 * 24 * time.Hour,
+		"baz": time.Hour,
+		"boo": time.Minute,
+	}
+
+	objs := sampleConfigMaps()
+
+	for _, obj := range objs {
+		cm, ok := obj.(*corev1.ConfigMap)
+		if !ok {
+			continue
+		}
+
+		if strings.HasPrefix(cm.Name, "kube-") {
+			cm.Annotations = map[string]string{"policy.example.com/exempt": "true"}
+		} else {
+			cm.Annotations = map[string]string{"owner": "https://example.com/teams/" + cm.Name}
+		}
+
+		cm.OwnerReferences = owners[cm.Name]
+
+		age, ok := ages[cm.Name]
+		if !ok {
+			age = 90 * 24 * time.Hour
+		}
+
+		cm.CreationTimestamp = metav1.NewTime(sampleNow.Add(-age))
+	}
+
+	return objs
+}
+
+func objNames[T client.Object](objs []T) []string {
+	names := make([]string, len(objs))
+	for i, obj := range objs {
+		names[i] = obj.GetName()
+	}
+
+	return names
+}
+
+func objNamespacedNames[T client.Object](objs []T) []string {
+	names := make([]string, len(objs))
+	for i, obj := range objs {
+		names[i] = obj.GetNamespace() + "/" + obj.GetName()
+	}
+
+	return names
+}
+
+// checkMatchNames compares the names of the matches, or the error, with the expected ones.
+func checkMatchNames(t *testing.T, desc string, want []string, wantErr string, got []string, err error) {
+	t.Helper()
+
+	if wantErr != "" {
+		if err == nil || err.Error() != wantErr {
+			t.Errorf("Expected error '%v' in %v, got '%v'", wantErr, desc, err)
+		}
+
+		return
+	}
+
+	if err != nil {
+		t.Errorf("Unexpected error '%v', in %v", err, desc)
+	}
+
+	less := func(a, b string) bool { return a < b }
+	if diff := cmp.Diff(want, got, cmpopts.SortSlices(less)); diff != "" {
+		t.Errorf("Mismatch in %v: %v", desc, diff)
+	}
+}
+
+func TestGetMatchesFilters(t *testing.T) {
+	t.Parallel()
+
+	tierIndexer := func(obj client.Object) []string {
+		cm, ok := obj.(*corev1.ConfigMap)
+		if !ok {
+			return nil
+		}
+
+		return []string{cm.Data["tier"]}
+	}
+
+	tests := map[string]struct {
+		target    Target
+		withIndex bool
+		want      []string
+		wantErr   string
+	}{
+		"indexed field": {
+			target:    Target{FieldSelector: "data.tier=gold"},
+			withIndex: true,
+			want:      []string{"bar", "baz", "boo"},
+		},
+		"unindexed field falls back to the client": {
+			target: Target{FieldSelector: "data.tier=gold"},
+			want:   []string{"bar", "baz", "boo"},
+		},
+		"unindexed field with a negation and an exclude": {
+			target: Target{FieldSelector: "data.tier!=gold", Exclude: []NonEmptyString{"kube-*"}},
+			want:   []string{"foo", "default"},
+		},
+		"missing fields have an empty value": {
+			target: Target{FieldSelector: "data.missing="},
+			want:   sampleNames,
+		},
+		"malformed field selector": {
+			target:  Target{FieldSelector: "data.tier"},
+			wantErr: "invalid selector: 'data.tier'; can't understand 'data.tier'",
+		},
+		"expression on the data": {
+			target: Target{Expression: `object.data.tier == "gold"`},
+			want:   []string{"bar", "baz", "boo"},
+		},
+		"expression on the metadata": {
+			target: Target{Expression: `object.metadata.labels.sample.startsWith("kube-")`},
+			want:   []string{"kube-one", "kube-two", "kube-three"},
+		},
+		"expression after an include": {
+			target: Target{
+				Include:    []NonEmptyString{"b*", "foo"},
+				Expression: `object.data.tier != "gold"`,
+			},
+			want: []string{"foo"},
+		},
+		"expression checking an optional field": {
+			target: Target{Expression: `has(object.data.missing) && object.data.missing == "yes"`},
+			want:   []string{},
+		},
+		"expression accessing a missing field": {
+			target:  Target{Expression: `object.data.missing == "yes"`},
+			wantErr: "error evaluating 'expression' on 'bar': no such key: missing",
+		},
+		"expression which is not a bool": {
+			target:  Target{Expression: `object.metadata.name`},
+			wantErr: "error evaluating 'expression' on 'bar': the expression must evaluate to a bool, not string",
+		},
+		"field requirement in": {
+			target: Target{FieldRequirements: []FieldRequirement{
+				{Path: "data.tier", Operator: FieldOpIn, Values: []string{"gold", "bronze"}},
+			}},
+			want: []string{"bar", "baz", "boo"},
+		},
+		"field requirement not in": {
+			target: Target{FieldRequirements: []FieldRequirement{
+				{Path: "data.tier", Operator: FieldOpNotIn, Values: []string{"gold"}},
+			}},
+			want: []string{"foo", "default", "kube-one", "kube-two", "kube-three"},
+		},
+		"field requirement not in is satisfied by a missing field": {
+			target: Target{FieldRequirements: []FieldRequirement{
+				{Path: "data.missing", Operator: FieldOpNotIn, Values: []string{"gold"}},
+			}},
+			want: sampleNames,
+		},
+		"field requirements exists and does not exist": {
+			target: Target{FieldRequirements: []FieldRequirement{
+				{Path: "metadata.labels.sample", Operator: FieldOpExists},
+				{Path: "data.missing", Operator: FieldOpDoesNotExist},
+			}},
+			want: sampleNames,
+		},
+		"field requirement path through a value which is not a map": {
+			target: Target{FieldRequirements: []FieldRequirement{
+				{Path: "data.tier.name", Operator: FieldOpExists},
+			}},
+			want: []string{},
+		},
+		"field requirement maps are not equal to any value": {
+			target: Target{FieldRequirements: []FieldRequirement{
+				{Path: "data", Operator: FieldOpNotIn, Values: []string{"gold"}},
+			}},
+			want: sampleNames,
+		},
+		"all field requirements must be satisfied, after the include": {
+			target: Target{
+				FieldRequirements: []FieldRequirement{
+					{Path: "data.tier", Operator: FieldOpIn, Values: []string{"silver"}},
+					{Path: "metadata.name", Operator: FieldOpNotIn, Values: []string{"kube-two"}},
+				},
+				Include: []NonEmptyString{"kube-*", "bar"},
+			},
+			want: []string{"kube-one", "kube-three"},
+		},
+		"annotation selector excluding the exempt objects": {
+			target: Target{AnnotationSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key:      "policy.example.com/exempt",
+					Operator: metav1.LabelSelectorOpNotIn,
+					Values:   []string{"true"},
+				}},
+			}},
+			want: []string{"foo", "bar", "baz", "boo", "default"},
+		},
+		"annotation values do not need to be valid label values": {
+			target: Target{AnnotationSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"owner": "https://example.com/teams/foo"},
+			}},
+			want: []string{"foo"},
+		},
+		"annotation selector combined with a label selector and an include": {
+			target: Target{
+				LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key:      "sample",
+					Operator: metav1.LabelSelectorOpNotIn,
+					Values:   []string{"kube-one"},
+				}}},
+				AnnotationSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key:      "policy.example.com/exempt",
+					Operator: metav1.LabelSelectorOpExists,
+				}}},
+				Include: []NonEmptyString{"*o*"},
+			},
+			want: []string{"kube-two"},
+		},
+		"empty annotation selector matches everything": {
+			target: Target{AnnotationSelector: &metav1.LabelSelector{}},
+			want:   sampleNames,
+		},
+		"owned by the ReplicaSets of a Deployment": {
+			target: Target{Owner: &OwnerSelector{Kind: "ReplicaSet", Name: "web-*"}},
+			want:   []string{"foo", "bar"},
+		},
+		"owner kind and name must match the same owner": {
+			target: Target{Owner: &OwnerSelector{Kind: "Secret", Name: "api-*"}},
+			want:   []string{},
+		},
+		"owned by a specific UID": {
+			target: Target{Owner: &OwnerSelector{UID: "uid-secret"}},
+			want:   []string{"baz"},
+		},
+		"owner name as a regex": {
+			target: Target{
+				Owner:     &OwnerSelector{Name: "(web|api)-[0-9a-f]{5}"},
+				MatchMode: RegexMatchMode,
+			},
+			want: []string{"foo", "bar", "baz"},
+		},
+		"empty owner selector matches any owner": {
+			target: Target{Owner: &OwnerSelector{}, Exclude: []NonEmptyString{"foo"}},
+			want:   []string{"bar", "baz"},
+		},
+		"no owner": {
+			target: Target{NoOwner: true, Include: []NonEmptyString{"b*", "default"}},
+			want:   []string{"boo", "default"},
+		},
+		"older than 90 days": {
+			target: Target{OlderThan: &metav1.Duration{Duration: 90 * 24 * time.Hour}},
+			want:   []string{"foo"},
+		},
+		"newer than 5 minutes": {
+			target: Target{NewerThan: &metav1.Duration{Duration: 5 * time.Minute}},
+			want:   []string{"boo"},
+		},
+		"between 5 minutes and 90 days, after an exclude": {
+			target: Target{
+				OlderThan: &metav1.Duration{Duration: 5 * time.Minute},
+				NewerThan: &metav1.Duration{Duration: 90 * 24 * time.Hour},
+				Exclude:   []NonEmptyString{"bar"},
+			},
+			want: []string{"baz"},
+		},
+	}
+
+	cmGVR := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	withClock := WithClock(clocktesting.NewFakePassiveClock(sampleNow))
+
+	for name, tcase := range tests {
+		builder := fake.NewClientBuilder().WithRuntimeObjects(detailedConfigMaps()...)
+		if tcase.withIndex {
+			builder = builder.WithIndex(&corev1.ConfigMap{}, "data.tier", tierIndexer)
+		}
+
+		got, err := tcase.target.GetMatches(context.TODO(), builder.Build(), &configMapResList{}, withClock)
+		checkMatchNames(t, "test '"+name+"'", tcase.want, tcase.wantErr, objNames(got), err)
+
+		// The fake dynamic client does not filter by fields; see TestGetMatchesDynamicFieldSelector.
+		if tcase.target.FieldSelector != "" {
+			continue
+		}
+
+		dynClient := dynfake.NewSimpleDynamicClient(scheme.Scheme, detailedConfigMaps()...)
+
+		gotDyn, err := tcase.target.GetMatchesDynamic(context.TODO(), dynClient.Resource(cmGVR), withClock)
+		checkMatchNames(t, "dynamic test '"+name+"'", tcase.want, tcase.wantErr, objNames(gotDyn), err)
+	}
+}
+
+func TestGetMatchesDynamicFieldSelector(t *testing.T) {
+	t.Parallel()
+
+	cmGVR := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+	tests := map[string]struct {
+		target       Target
+		rejectFields bool
+		forbidden    bool
+		want         []string
+		wantErr      bool
+	}{
+		"field selector sent to the server": {
+			target: Target{FieldSelector: "metadata.name=foo"},
+			// The fake dynamic client does not filter by fields, so everything is returned.
+			want: sampleNames,
+		},
+		"rejected field selector falls back to the client": {
+			target:       Target{FieldSelector: "data.tier=gold"},
+			rejectFields: true,
+			want:         []string{"bar", "baz", "boo"},
+		},
+		"rejected field selector with a namespace and an include": {
+			target: Target{
+				FieldSelector: "data.tier=silver",
+				Namespace:     "default",
+				Include:       []NonEmptyString{"kube-*"},
+			},
+			rejectFields: true,
+			want:         []string{"kube-one", "kube-two", "kube-three"},
+		},
+		"forbidden list does not fall back": {
+			target:    Target{FieldSelector: "data.tier=gold"},
+			forbidden: true,
+			wantErr:   true,
+		},
+	}
+
+	for name, tcase := range tests {
+		dynClient := dynfake.NewSimpleDynamicClient(scheme.Scheme, sampleConfigMaps()...)
+
+		var gotFieldSelectors []string
+
+		dynClient.PrependReactor("list", "configmaps",
+			func(action clienttesting.Action) (bool, runtime.Object, error) {
+				listAction, ok := action.(clienttesting.ListAction)
+				if !ok {
+					return false, nil, nil
+				}
+
+				fieldSel := listAction.GetListRestrictions().Fields.String()
+				gotFieldSelectors = append(gotFieldSelectors, fieldSel)
+
+				if tcase.rejectFields && fieldSel != "" {
+					return true, nil, k8sErrors.NewBadRequest("field label not supported: " + fieldSel)
+				}
+
+				if tcase.forbidden {
+					return true, nil, k8sErrors.NewForbidden(cmGVR.GroupResource(), "", errors.New("no access"))
+				}
+
+				return false, nil, nil
+			})
+
+		got, err := tcase.target.GetMatchesDynamic(context.TODO(), dynClient.Resource(cmGVR))
+		if tcase.wantErr {
+			if !k8sErrors.IsForbidden(err) {
+				t.Errorf("Expected the Forbidden error to be returned in test '%v', got '%v'", name, err)
+			}
+
+			if len(gotFieldSelectors) != 1 {
+				t.Errorf("Expected only one list call in test '%v', got %v", name, gotFieldSelectors)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Unexpected error '%v', in test '%v'", err, name)
+		}
+
+		less := func(a, b string) bool { return a < b }
+		if diff := cmp.Diff(tcase.want, objNames(got), cmpopts.SortSlices(less)); diff != "" {
+			t.Errorf("Mismatch in test '%v': %v", name, diff)
+		}
+
+		if len(gotFieldSelectors) == 0 || gotFieldSelectors[0] != tcase.target.FieldSelector {
+			t.Errorf("Expected the field selector to be sent to the server in test '%v', got %v",
+				name, gotFieldSelectors)
+		}
+	}
+}
+
+// configMapPage returns the chunk of the given ConfigMaps starting at the index encoded in the
+// continue token, and the continue token for the next chunk, emulating an API server.
+func configMapPage(all []corev1.ConfigMap, limit int64, continueToken string) ([]corev1.ConfigMap, string) {
+	start, _ := strconv.Atoi(continueToken)
+
+	if limit == 0 || start+int(limit) >= len(all) {
+		return all[start:], ""
+	}
+
+	end := start + int(limit)
+
+	return all[start:end], strconv.Itoa(end)
+}
+
+func TestGetMatchesPaginated(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		target      Target
+		pageSize    int64
+		expireToken string
+		alwaysFail  bool
+		likeCache   bool
+		wantPages   int
+		want        []string
+		wantErr     bool
+	}{
+		"no page size lists everything at once": {
+			target:    Target{Exclude: []NonEmptyString{"kube-*"}},
+			wantPages: 1,
+			want:      []string{"foo", "bar", "baz", "boo", "default"},
+		},
+		"page size smaller than the list": {
+			target:    Target{Exclude: []NonEmptyString{"kube-*"}},
+			pageSize:  3,
+			wantPages: 3,
+			want:      []string{"foo", "bar", "baz", "boo", "default"},
+		},
+		"page size larger than the list": {
+			target:    Target{Include: []NonEmptyString{"b*"}},
+			pageSize:  100,
+			wantPages: 1,
+			want:      []string{"bar", "baz", "boo"},
+		},
+		"expired continue token restarts the list": {
+			target:      Target{Include: []NonEmptyString{"*o*"}},
+			pageSize:    2,
+			expireToken: "4",
+			wantPages:   6, // two pages before the expiration, then four after the restart
+			want:        []string{"foo", "boo", "kube-one", "kube-two"},
+		},
+		"a cache which truncates the list is listed again without a limit": {
+			target:    Target{Exclude: []NonEmptyString{"kube-*"}},
+			pageSize:  3,
+			likeCache: true,
+			wantPages: 2,
+			want:      []string{"foo", "bar", "baz", "boo", "default"},
+		},
+		"a cache which returns fewer items than the limit is not listed again": {
+			target:    Target{Include: []NonEmptyString{"b*"}},
+			pageSize:  100,
+			likeCache: true,
+			wantPages: 1,
+			want:      []string{"bar", "baz", "boo"},
+		},
+		"repeatedly expired continue tokens cause an error": {
+			target:      Target{},
+			pageSize:    2,
+			expireToken: "2",
+			alwaysFail:  true,
+			wantErr:     true,
+		},
+	}
+
+	for name, tcase := range tests {
+		baseClient := fake.NewClientBuilder().WithRuntimeObjects(sampleConfigMaps()...).Build()
+		expired := false
+		pages := 0
+
+		pagingClient := interceptor.NewClient(baseClient, interceptor.Funcs{
+			List: func(
+				ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption,
+			) error {
+				listOpts := client.ListOptions{}
+				listOpts.ApplyOptions(opts)
+
+				if tcase.expireToken != "" && listOpts.Continue == tcase.expireToken &&
+					(!expired || tcase.alwaysFail) {
+					expired = true
+
+					return k8sErrors.NewResourceExpired("the continue token is too old")
+				}
+
+				all := corev1.ConfigMapList{}
+				if err := c.List(ctx, &all, &client.ListOptions{Namespace: listOpts.Namespace}); err != nil {
+					return err
+				}
+
+				// Sort to match the order of the sampleNames
+				slices.SortFunc(all.Items, func(a, b corev1.ConfigMap) int {
+					return slices.Index(sampleNames, a.Name) - slices.Index(sampleNames, b.Name)
+				})
+
+				pages++
+				items, next := configMapPage(all.Items, listOpts.Limit, listOpts.Continue)
+
+				if tcase.likeCache {
+					if listOpts.Continue != "" {
+						return errors.New("continue list option is not supported by the cache")
+					}
+
+					// Like the controller-runtime cache, truncate the list without a continue token.
+					next = ""
+				}
+
+				cmList, ok := list.(*corev1.ConfigMapList)
+				if !ok {
+					return errors.New("unexpected list type")
+				}
+
+				// Re-use the existing slice, like decoding into the list would.
+				cmList.Items = append(cmList.Items[:0], items...)
+				cmList.Continue = next
+
+				return nil
+			},
+		})
+
+		got, err := tcase.target.GetMatches(context.TODO(), pagingClient, &configMapResList{},
+			WithPageSize(tcase.pageSize))
+		if tcase.wantErr {
+			if !k8sErrors.IsResourceExpired(err) {
+				t.Errorf("Expected a ResourceExpired error in test '%v', got '%v'", name, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Unexpected error '%v', in test '%v'", err, name)
+		}
+
+		if diff := cmp.Diff(tcase.want, objNames(got)); diff != "" {
+			t.Errorf("Mismatch in test '%v': %v", name, diff)
+		}
+
+		if pages != tcase.wantPages {
+			t.Errorf("Expected %v pages to be listed in test '%v', got %v", tcase.wantPages, name, pages)
+		}
+	}
+}
+
+// listFuncResourceInterface implements the List method of a dynamic.ResourceInterface with the
+// listFunc. Any other methods will panic.
+type listFuncResourceInterface struct {
+	dynamic.ResourceInterface
+	listFunc func(opts metav1.ListOptions) (*unstructured.UnstructuredList, error)
+}
+
+func (f listFuncResourceInterface) List(
+	_ context.Context, opts metav1.ListOptions,
+) (*unstructured.UnstructuredList, error) {
+	return f.listFunc(opts)
+}
+
+func TestGetMatchesDynamicPaginated(t *testing.T) {
+	t.Parallel()
+
+	allCMs := make([]corev1.ConfigMap, 0, len(sampleNames))
+	for _, obj := range sampleConfigMaps() {
+		cm, ok := obj.(*corev1.ConfigMap)
+		if ok {
+			allCMs = append(allCMs, *cm)
+		}
+	}
+
+	tests := map[string]struct {
+		target      Target
+		pageSize    int64
+		expireToken string
+		wantPages   int
+		want        []string
+	}{
+		"page size smaller than the list": {
+			target:    Target{Include: []NonEmptyString{"kube-*", "default"}},
+			pageSize:  3,
+			wantPages: 3,
+			want:      []string{"default", "kube-one", "kube-two", "kube-three"},
+		},
+		"expired continue token restarts the list": {
+			target:      Target{Exclude: []NonEmptyString{"b*"}},
+			pageSize:    5,
+			expireToken: "5",
+			wantPages:   3,
+			want:        []string{"foo", "default", "kube-one", "kube-two", "kube-three"},
+		},
+	}
+
+	for name, tcase := range tests {
+		expired := false
+		pages := 0
+
+		iface := listFuncResourceInterface{
+			listFunc: func(opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+				if tcase.expireToken != "" && opts.Continue == tcase.expireToken && !expired {
+					expired = true
+
+					return nil, k8sErrors.NewResourceExpired("the continue token is too old")
+				}
+
+				pages++
+				items, next := configMapPage(allCMs, opts.Limit, opts.Continue)
+
+				list := &unstructured.UnstructuredList{}
+				list.SetContinue(next)
+
+				for i := range items {
+					content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&items[i])
+					if err != nil {
+						return nil, err
+					}
+
+					list.Items = append(list.Items, unstructured.Unstructured{Object: content})
+				}
+
+				return list, nil
+			},
+		}
+
+		got, err := tcase.target.GetMatchesDynamic(context.TODO(), iface, WithPageSize(tcase.pageSize))
+		if err != nil {
+			t.Errorf("Unexpected error '%v', in test '%v'", err, name)
+		}
+
+		if diff := cmp.Diff(tcase.want, objNames(got)); diff != "" {
+			t.Errorf("Mismatch in test '%v': %v", name, diff)
+		}
+
+		if pages != tcase.wantPages {
+			t.Errorf("Expected %v pages to be listed in test '%v', got %v", tcase.wantPages, name, pages)
+		}
+	}
+}
+
+// sampleNamespacedObjects returns some Namespaces, each containing an "app" and a "db" ConfigMap,
+// labeled with sample=<name>. The namespaces starting with "team-" have a "team" label.
+func sampleNamespacedObjects() []runtime.Object {
+	objs := make([]runtime.Object, 0)
+
+	for _, ns := range []string{"team-b", "default", "team-a", "kube-system", "team-c"} {
+		labels := map[string]string{}
+		if team, found := strings.CutPrefix(ns, "team-"); found {
+			labels["team"] = team
+		}
+
+		objs = append(objs, &corev1.Namespace{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+			ObjectMeta: metav1.ObjectMeta{Name: ns, Labels: labels},
+		})
+
+		for _, name := range []string{"app", "db"} {
+			objs = append(objs, &corev1.ConfigMap{
+				TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: ns,
+					Labels:    map[string]string{"sample": name},
+				},
+			})
+		}
+	}
+
+	return objs
+}
+
+func TestGetMatchesNamespaceSelector(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		target Target
+		want   []string
+	}{
+		"include namespaces by name": {
+			target: Target{NamespaceSelector: &NamespaceSelector{
+				Include: []NonEmptyString{"team-*"},
+				Exclude: []NonEmptyString{"team-c"},
+			}},
+			want: []string{"team-a/app", "team-a/db", "team-b/app", "team-b/db"},
+		},
+		"select namespaces by label, and objects by name": {
+			target: Target{
+				NamespaceSelector: &NamespaceSelector{
+					LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+						Key:      "team",
+						Operator: metav1.LabelSelectorOpIn,
+						Values:   []string{"b", "c"},
+					}}},
+				},
+				Include: []NonEmptyString{"db"},
+			},
+			want: []string{"team-b/db", "team-c/db"},
+		},
+		"namespace is also set": {
+			target: Target{
+				Namespace:         "team-b",
+				NamespaceSelector: &NamespaceSelector{Include: []NonEmptyString{"team-*"}},
+			},
+			want: []string{"team-b/app", "team-b/db"},
+		},
+		"namespace is set but not selected": {
+			target: Target{
+				Namespace:         "default",
+				NamespaceSelector: &NamespaceSelector{Include: []NonEmptyString{"team-*"}},
+			},
+			want: []string{},
+		},
+		"empty namespace selector matches nothing": {
+			target: Target{NamespaceSelector: &NamespaceSelector{}},
+			want:   []string{},
+		},
+	}
+
+	for name, tcase := range tests {
+		fakeClient := fake.NewClientBuilder().WithRuntimeObjects(sampleNamespacedObjects()...).Build()
+
+		got, err := tcase.target.GetMatches(context.TODO(), fakeClient, &configMapResList{})
+		if err != nil {
+			t.Errorf("Unexpected error '%v', in test '%v'", err, name)
+		}
+
+		if diff := cmp.Diff(tcase.want, objNamespacedNames(got)); diff != "" {
+			t.Errorf("Mismatch in test '%v': %v", name, diff)
+		}
+
+		dynClient := dynfake.NewSimpleDynamicClient(scheme.Scheme, sampleNamespacedObjects()...)
+		cmIface := dynClient.Resource(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"})
+
+		gotDyn, err := tcase.target.GetMatchesDynamic(context.TODO(), cmIface, WithNamespaceReader(fakeClient))
+		if err != nil {
+			t.Errorf("Unexpected error '%v' from GetMatchesDynamic, in test '%v'", err, name)
+		}
+
+		if diff := cmp.Diff(tcase.want, objNamespacedNames(gotDyn)); diff != "" {
+			t.Errorf("Mismatch from GetMatchesDynamic in test '%v': %v", name, diff)
+		}
+
+		_, err = tcase.target.GetMatchesDynamic(context.TODO(), cmIface)
+		if !errors.Is(err, ErrNoNamespaceReader) {
+			t.Errorf("Expected ErrNoNamespaceReader in test '%v', got '%v'", name, err)
+		}
+	}
+}
+
+func TestGetMatchesMetadata(t *testing.T) {
+	t.Parallel()
+
+	cmGVK := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+
+	tests := map[string]struct {
+		target Target
+		want   []string
+	}{
+		"include and exclude patterns": {
+			target: Target{Include: []NonEmptyString{"b*", "kube-*"}, Exclude: []NonEmptyString{"*-t*"}},
+			want:   []string{"default/bar", "default/baz", "default/boo", "default/kube-one"},
+		},
+		"label selector": {
+			target: Target{LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      "sample",
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   []string{"foo", "default"},
+			}}}},
+			want: []string{"default/default", "default/foo"},
+		},
+		"metadata field selector falls back to the client": {
+			target: Target{FieldSelector: "metadata.name!=foo", Include: []NonEmptyString{"?oo"}},
+			want:   []string{"default/boo"},
+		},
+		"namespace selector": {
+			target: Target{
+				NamespaceSelector: &NamespaceSelector{Include: []NonEmptyString{"team-*"}},
+				Include:           []NonEmptyString{"db"},
+			},
+			want: []string{"team-a/db", "team-b/db", "team-c/db"},
+		},
+	}
+
+	objs := append(sampleConfigMaps(), sampleNamespacedObjects()...)
+
+	for name, tcase := range tests {
+		fakeClient := fake.NewClientBuilder().WithRuntimeObjects(objs...).Build()
+
+		got, err := tcase.target.GetMatchesMetadata(context.TODO(), fakeClient, cmGVK)
+		if err != nil {
+			t.Errorf("Unexpected error '%v', in test '%v'", err, name)
+		}
+
+		less := func(a, b string) bool { return a < b }
+		if diff := cmp.Diff(tcase.want, objNamespacedNames(got), cmpopts.SortSlices(less)); diff != "" {
+			t.Errorf("Mismatch in test '%v': %v", name, diff)
+		}
+
+		for _, meta := range got {
+			if meta.GroupVersionKind() != cmGVK {
+				t.Errorf("Expected the GVK of '%v' to be %v in test '%v', got %v",
+					meta.GetName(), cmGVK, name, meta.GroupVersionKind())
+			}
+		}
+	}
+}
+
+func TestGetTypedMatches(t *testing.T) {
+	t.Parallel()
+
+	cmItems := func(l *corev1.ConfigMapList) []corev1.ConfigMap { return l.Items }
+
+	tests := map[string]struct {
+		target Target
+		opts   []MatchOption
+		want   []string
+	}{
+		"include and exclude": {
+			target: Target{Include: []NonEmptyString{"b*", "kube-*"}, Exclude: []NonEmptyString{"*-t*"}},
+			want:   []string{"default/bar", "default/baz", "default/boo", "default/kube-one"},
+		},
+		"paginated": {
+			target: Target{Include: []NonEmptyString{"b*", "kube-*"}, Exclude: []NonEmptyString{"*-t*"}},
+			opts:   []MatchOption{WithPageSize(2)},
+			want:   []string{"default/bar", "default/baz", "default/boo", "default/kube-one"},
+		},
+		"namespace selector": {
+			target: Target{
+				NamespaceSelector: &NamespaceSelector{Include: []NonEmptyString{"team-*"}},
+				Include:           []NonEmptyString{"app"},
+			},
+			want: []string{"team-a/app", "team-b/app", "team-c/app"},
+		},
+	}
+
+	objs := append(sampleConfigMaps(), sampleNamespacedObjects()...)
+
+	for name, tcase := range tests {
+		fakeClient := fake.NewClientBuilder().WithRuntimeObjects(objs...).Build()
+
+		got, err := GetTypedMatches(context.TODO(), tcase.target, fakeClient, cmItems, tcase.opts...)
+		if err != nil {
+			t.Errorf("Unexpected error '%v', in test '%v'", err, name)
+		}
+
+		less := func(a, b string) bool { return a < b }
+		if diff := cmp.Diff(tcase.want, objNamespacedNames(got), cmpopts.SortSlices(less)); diff != "" {
+			t.Errorf("Mismatch in test '%v': %v", name, diff)
+		}
+	}
+}
+
+func TestGetMatchesCopies(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		target     Target
+		opts       []MatchOption
+		wantCopied bool
+	}{
+		"a single list is not copied": {
+			target:     Target{Include: []NonEmptyString{"foo"}},
+			wantCopied: false,
+		},
+		"pages are copied": {
+			target:     Target{Include: []NonEmptyString{"foo"}},
+			opts:       []MatchOption{WithPageSize(2)},
+			wantCopied: true,
+		},
+		"namespaces are copied": {
+			target: Target{
+				Include:           []NonEmptyString{"foo"},
+				NamespaceSelector: &NamespaceSelector{Include: []NonEmptyString{"default"}},
+			},
+			opts: []MatchOption{WithNamespaceReader(fake.NewClientBuilder().WithRuntimeObjects(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}).Build())},
+			wantCopied: true,
+		},
+	}
+
+	for name, tcase := range tests {
+		compiled, err := tcase.target.Compile()
+		if err != nil {
+			t.Fatalf("Unexpected error '%v', in test '%v'", err, name)
+		}
+
+		list := &configMapResList{}
+		r := fake.NewClientBuilder().WithRuntimeObjects(sampleConfigMaps()...).Build()
+
+		matches, copied, err := compiled.getMatches(context.TODO(), r, list, tcase.opts)
+		if err != nil {
+			t.Errorf("Unexpected error '%v', in test '%v'", err, name)
+		}
+
+		if copied != tcase.wantCopied {
+			t.Errorf("Expected copied to be %v in test '%v'", tcase.wantCopied, name)
+		}
+
+		if len(matches) != 1 {
+			t.Fatalf("Expected one match in test '%v', got %v", name, objNames(matches))
+		}
+
+		inList := false
+		for i := range list.ConfigMapList.Items {
+			if matches[0] == &list.ConfigMapList.Items[i] {
+				inList = true
+			}
+		}
+
+		if inList == copied {
+			t.Errorf("Expected the match to be in the list only when it is not copied, in test '%v'", name)
+		}
+	}
+}
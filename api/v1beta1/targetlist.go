@@ -0,0 +1,106 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//+kubebuilder:validation:MinItems=1
+
+// TargetList is a list of Targets, which matches the union of the objects matched by each Target.
+// In other words, an object is matched if *any* of the Targets match it.
+type TargetList []Target
+
+// GetMatches returns a list of resources on the cluster, matched by any of the Targets in the
+// list. The matches from each Target are combined in the order of the list, with any duplicates
+// (by namespace and name) removed, keeping the first occurrence. Within the matches from each
+// Target, the order is the same as in `Target.GetMatches`. The returned items are copies, so they
+// are not affected by later uses of the ResourceList.
+func (tl TargetList) GetMatches(
+	ctx context.Context, r client.Reader, list ResourceList, opts ...MatchOption,
+) ([]client.Object, error) {
+	matches := make([]client.Object, 0)
+	seen := make(map[string]bool)
+
+	for _, t := range tl {
+		compiled, err := t.Compile()
+		if err != nil {
+			return nil, err
+		}
+
+		targetMatches, copied, err := compiled.getMatches(ctx, r, list, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		if !copied {
+			copyObjects(targetMatches)
+		}
+
+		matches = appendUnique(matches, seen, targetMatches)
+	}
+
+	return matches, nil
+}
+
+// GetMatchesDynamic returns a list of resources on the cluster, matched by any of the Targets in
+// the list. The matches are combined like in `GetMatches`; see `Target.GetMatchesDynamic` for
+// more details on how each Target is evaluated.
+func (tl TargetList) GetMatchesDynamic(
+	ctx context.Context, iface dynamic.ResourceInterface, opts ...MatchOption,
+) ([]*unstructured.Unstructured, error) {
+	matches := make([]*unstructured.Unstructured, 0)
+	seen := make(map[string]bool)
+
+	for _, t := range tl {
+		targetMatches, err := t.GetMatchesDynamic(ctx, iface, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		matches = appendUnique(matches, seen, targetMatches)
+	}
+
+	return matches, nil
+}
+
+// GetMatchesMetadata returns the metadata of the resources on the cluster, matched by any of the
+// Targets in the list. The matches are combined like in `GetMatches`; see
+// `Target.GetMatchesMetadata` for more details on how each Target is evaluated.
+func (tl TargetList) GetMatchesMetadata(
+	ctx context.Context, r client.Reader, gvk schema.GroupVersionKind, opts ...MatchOption,
+) ([]*metav1.PartialObjectMetadata, error) {
+	matches := make([]*metav1.PartialObjectMetadata, 0)
+	seen := make(map[string]bool)
+
+	for _, t := range tl {
+		targetMatches, err := t.GetMatchesMetadata(ctx, r, gvk, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		matches = appendUnique(matches, seen, targetMatches)
+	}
+
+	return matches, nil
+}
+
+// Validate checks each Target in the list, returning all problems with paths relative to the
+// given fldPath, for example `spec.targets[1].include[0]`. See `Target.Validate` for more details.
+func (tl TargetList) Validate(fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	for i, t := range tl {
+		allErrs = append(allErrs, t.Validate(fldPath.Index(i))...)
+	}
+
+	return allErrs
+}
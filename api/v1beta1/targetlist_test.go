@@ -0,0 +1,125 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	dynfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestTargetListGetMatches(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		targets TargetList
+		want    []string
+	}{
+		"empty list": {
+			targets: TargetList{},
+			want:    []string{},
+		},
+		"targets in different namespaces with their own label selectors": {
+			targets: TargetList{{
+				Namespace: "team-a",
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{
+					"sample": "app",
+				}},
+			}, {
+				Namespace: "team-c",
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{
+					"sample": "db",
+				}},
+			}},
+			want: []string{"team-a/app", "team-c/db"},
+		},
+		"overlapping targets are deduplicated, in the order of the list": {
+			targets: TargetList{{
+				Namespace: "team-b",
+				Include:   []NonEmptyString{"db"},
+			}, {
+				NamespaceSelector: &NamespaceSelector{Include: []NonEmptyString{"team-[ab]"}},
+			}, {
+				Namespace: "team-b",
+			}},
+			want: []string{"team-b/db", "team-a/app", "team-a/db", "team-b/app"},
+		},
+	}
+
+	for name, tcase := range tests {
+		fakeClient := fake.NewClientBuilder().WithRuntimeObjects(sampleNamespacedObjects()...).Build()
+
+		got, err := tcase.targets.GetMatches(context.TODO(), fakeClient, &configMapResList{})
+		if err != nil {
+			t.Errorf("Unexpected error '%v', in test '%v'", err, name)
+		}
+
+		if diff := cmp.Diff(tcase.want, objNamespacedNames(got)); diff != "" {
+			t.Errorf("Mismatch in test '%v': %v", name, diff)
+		}
+
+		dynClient := dynfake.NewSimpleDynamicClient(scheme.Scheme, sampleNamespacedObjects()...)
+		cmIface := dynClient.Resource(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"})
+
+		gotDyn, err := tcase.targets.GetMatchesDynamic(context.TODO(), cmIface, WithNamespaceReader(fakeClient))
+		if err != nil {
+			t.Errorf("Unexpected error '%v' from GetMatchesDynamic, in test '%v'", err, name)
+		}
+
+		if diff := cmp.Diff(tcase.want, objNamespacedNames(gotDyn)); diff != "" {
+			t.Errorf("Mismatch from GetMatchesDynamic in test '%v': %v", name, diff)
+		}
+
+		gvk := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+
+		gotMeta, err := tcase.targets.GetMatchesMetadata(context.TODO(), fakeClient, gvk)
+		if err != nil {
+			t.Errorf("Unexpected error '%v' from GetMatchesMetadata, in test '%v'", err, name)
+		}
+
+		if diff := cmp.Diff(tcase.want, objNamespacedNames(gotMeta)); diff != "" {
+			t.Errorf("Mismatch from GetMatchesMetadata in test '%v': %v", name, diff)
+		}
+	}
+}
+
+func TestTargetListGetMatchesError(t *testing.T) {
+	t.Parallel()
+
+	fakeClient := fake.NewClientBuilder().WithRuntimeObjects(sampleNamespacedObjects()...).Build()
+
+	targets := TargetList{{Include: []NonEmptyString{"app"}}, {Include: []NonEmptyString{"kube-[system"}}}
+
+	_, err := targets.GetMatches(context.TODO(), fakeClient, &configMapResList{})
+
+	want := "error parsing 'include' pattern 'kube-[system': syntax error in pattern"
+	if err == nil || err.Error() != want {
+		t.Errorf("Expected error '%v', got '%v'", want, err)
+	}
+}
+
+func TestTargetListValidate(t *testing.T) {
+	t.Parallel()
+
+	targets := TargetList{
+		{Include: []NonEmptyString{"app"}},
+		{Include: []NonEmptyString{"kube-[system"}, FieldSelector: "data.tier"},
+	}
+
+	got := errorSummaries(targets.Validate(field.NewPath("spec", "targets")))
+	want := []string{
+		"FieldValueInvalid spec.targets[1].fieldSelector",
+		"FieldValueInvalid spec.targets[1].include[0]",
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Mismatch: %v", diff)
+	}
+}
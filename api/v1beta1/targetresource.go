@@ -0,0 +1,102 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// TargetResource is a Target for objects of an arbitrary kind, which is specified in the resource
+// itself instead of by the caller. This allows a policy to target kinds which are not known when the
+// controller is built, for example the kinds defined by CRDs.
+type TargetResource struct {
+	// APIVersion is the group and version of the kind to target, for example 'apps/v1'.
+	//+kubebuilder:validation:MinLength=1
+	APIVersion string `json:"apiVersion"`
+
+	// Kind is the kind to target, for example 'Deployment'.
+	//+kubebuilder:validation:MinLength=1
+	Kind string `json:"kind"`
+
+	Target `json:",inline"`
+}
+
+//+kubebuilder:object:generate=false
+
+// KindNotFoundError is returned when the kind of a TargetResource is not known to the RESTMapper,
+// for example when the CRD defining the kind has not been installed yet. Callers can check for it
+// with `errors.As`, and will usually want to retry later instead of treating it as a failure.
+type KindNotFoundError struct {
+	GroupVersionKind schema.GroupVersionKind
+	Err              error
+}
+
+func (e *KindNotFoundError) Error() string {
+	return fmt.Sprintf("the kind '%s' in apiVersion '%s' was not found on the cluster: %v",
+		e.GroupVersionKind.Kind, e.GroupVersionKind.GroupVersion().String(), e.Err)
+}
+
+func (e *KindNotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// GroupVersionKind returns the parsed APIVersion and Kind of the TargetResource.
+func (tr TargetResource) GroupVersionKind() (schema.GroupVersionKind, error) {
+	gv, err := schema.ParseGroupVersion(tr.APIVersion)
+	if err != nil {
+		return schema.GroupVersionKind{}, fmt.Errorf("error parsing 'apiVersion' '%s': %w", tr.APIVersion, err)
+	}
+
+	return gv.WithKind(tr.Kind), nil
+}
+
+// RESTMapping resolves the kind of the TargetResource with the given RESTMapper. If the mapper does
+// not know about the kind, a *KindNotFoundError is returned.
+func (tr TargetResource) RESTMapping(mapper meta.RESTMapper) (*meta.RESTMapping, error) {
+	gvk, err := tr.GroupVersionKind()
+	if err != nil {
+		return nil, err
+	}
+
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, &KindNotFoundError{GroupVersionKind: gvk, Err: err}
+		}
+
+		return nil, err
+	}
+
+	return mapping, nil
+}
+
+// GetMatches returns a list of resources on the cluster, matched by the TargetResource. The kind is
+// resolved with the provided RESTMapper, and the resources are listed with the dynamic client. If
+// the kind is not known to the RESTMapper (for example, when its CRD is not installed yet), a
+// *KindNotFoundError is returned. When the kind is not namespaced, the Namespace and
+// NamespaceSelector of the Target are ignored. Otherwise, this behaves like
+// `Target.GetMatchesDynamic`, including the requirement for a namespace reader when the Target has
+// a NamespaceSelector.
+func (tr TargetResource) GetMatches(
+	ctx context.Context, mapper meta.RESTMapper, dynClient dynamic.Interface, opts ...MatchOption,
+) ([]*unstructured.Unstructured, error) {
+	mapping, err := tr.RESTMapping(mapper)
+	if err != nil {
+		return nil, err
+	}
+
+	target := tr.Target
+
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		target.Namespace = ""
+		target.NamespaceSelector = nil
+	}
+
+	return target.GetMatchesDynamic(ctx, dynClient.Resource(mapping.Resource), opts...)
+}
@@ -0,0 +1,183 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	dynfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func sampleRESTMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+
+	return mapper
+}
+
+// namespacedNames returns the "namespace/name" of each object, or just the name when it is not
+// namespaced.
+func namespacedNames(objs []*unstructured.Unstructured) []string {
+	names := make([]string, len(objs))
+
+	for i, obj := range objs {
+		names[i] = obj.GetName()
+		if obj.GetNamespace() != "" {
+			names[i] = obj.GetNamespace() + "/" + obj.GetName()
+		}
+	}
+
+	return names
+}
+
+func TestTargetResourceGetMatches(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		tr   TargetResource
+		want []string
+	}{
+		"configmaps in a namespace": {
+			tr: TargetResource{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+				Target:     Target{Namespace: "team-a"},
+			},
+			want: []string{"team-a/app", "team-a/db"},
+		},
+		"configmaps in selected namespaces": {
+			tr: TargetResource{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+				Target: Target{
+					NamespaceSelector: &NamespaceSelector{Include: []NonEmptyString{"team-*"}},
+					Include:           []NonEmptyString{"db"},
+				},
+			},
+			want: []string{"team-a/db", "team-b/db", "team-c/db"},
+		},
+		"namespaces ignore the namespace": {
+			tr: TargetResource{
+				APIVersion: "v1",
+				Kind:       "Namespace",
+				Target: Target{
+					LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+						Key:      "team",
+						Operator: metav1.LabelSelectorOpExists,
+					}}},
+					Namespace: "default",
+					Exclude:   []NonEmptyString{"team-b"},
+				},
+			},
+			want: []string{"team-a", "team-c"},
+		},
+		"namespaces ignore the namespace selector": {
+			tr: TargetResource{
+				APIVersion: "v1",
+				Kind:       "Namespace",
+				Target: Target{
+					NamespaceSelector: &NamespaceSelector{Include: []NonEmptyString{"team-a"}},
+					Include:           []NonEmptyString{"kube-*", "default"},
+				},
+			},
+			want: []string{"default", "kube-system"},
+		},
+	}
+
+	less := func(a, b string) bool { return a < b }
+
+	for name, tcase := range tests {
+		dynClient := dynfake.NewSimpleDynamicClient(scheme.Scheme, sampleNamespacedObjects()...)
+		nsReader := fake.NewClientBuilder().WithRuntimeObjects(sampleNamespacedObjects()...).Build()
+
+		got, err := tcase.tr.GetMatches(context.TODO(), sampleRESTMapper(), dynClient,
+			WithNamespaceReader(nsReader))
+		if err != nil {
+			t.Errorf("Unexpected error '%v', in test '%v'", err, name)
+		}
+
+		if diff := cmp.Diff(tcase.want, namespacedNames(got), cmpopts.SortSlices(less)); diff != "" {
+			t.Errorf("Mismatch in test '%v': %v", name, diff)
+		}
+	}
+}
+
+func TestTargetResourceKindNotFound(t *testing.T) {
+	t.Parallel()
+
+	tr := TargetResource{APIVersion: "example.com/v1", Kind: "Widget"}
+	dynClient := dynfake.NewSimpleDynamicClient(scheme.Scheme)
+
+	_, err := tr.GetMatches(context.TODO(), sampleRESTMapper(), dynClient)
+
+	var notFound *KindNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("Expected a KindNotFoundError, got '%v'", err)
+	}
+
+	wantGVK := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}
+	if notFound.GroupVersionKind != wantGVK {
+		t.Errorf("Expected the GroupVersionKind '%v', got '%v'", wantGVK, notFound.GroupVersionKind)
+	}
+
+	if !meta.IsNoMatchError(err) {
+		t.Errorf("Expected the error to wrap a NoMatchError, got '%v'", err)
+	}
+
+	_, err = (TargetResource{APIVersion: "a/b/c", Kind: "Widget"}).GetMatches(
+		context.TODO(), sampleRESTMapper(), dynClient)
+	if err == nil || errors.As(err, &notFound) {
+		t.Errorf("Expected an error parsing the apiVersion, got '%v'", err)
+	}
+}
+
+func TestTargetResourceValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		tr       TargetResource
+		wantErrs []string
+	}{
+		"valid": {
+			tr:       TargetResource{APIVersion: "apps/v1", Kind: "Deployment"},
+			wantErrs: []string{},
+		},
+		"missing apiVersion and kind": {
+			tr: TargetResource{},
+			wantErrs: []string{
+				"FieldValueInvalid spec.target.apiVersion",
+				"FieldValueRequired spec.target.kind",
+			},
+		},
+		"invalid apiVersion and target": {
+			tr: TargetResource{
+				APIVersion: "a/b/c",
+				Kind:       "Widget",
+				Target:     Target{Include: []NonEmptyString{"[a"}},
+			},
+			wantErrs: []string{
+				"FieldValueInvalid spec.target.apiVersion",
+				"FieldValueInvalid spec.target.include[0]",
+			},
+		},
+	}
+
+	for name, tcase := range tests {
+		errs := tcase.tr.Validate(field.NewPath("spec", "target"))
+		if diff := cmp.Diff(tcase.wantErrs, errorSummaries(errs)); diff != "" {
+			t.Errorf("Mismatch in the validation errors in test '%v': %v", name, diff)
+		}
+	}
+}
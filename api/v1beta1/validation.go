@@ -0,0 +1,269 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Validate checks the PolicyCoreSpec for problems that would otherwise only be found when the
+// policy is reconciled, and returns all of them, with paths relative to the given fldPath. For
+// example, when called with `field.NewPath("spec")`, a malformed pattern might be reported at
+// `spec.namespaceSelector.exclude[2]`. This is intended to be used by admission webhooks and at
+// the start of reconciles.
+func (spec PolicyCoreSpec) Validate(fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if spec.Severity != "" && !slices.Contains(severities(), spec.Severity) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("severity"), spec.Severity, severities()))
+	}
+
+	if ra := spec.RemediationAction; ra != "" && !ra.IsEnforce() && !ra.IsInform() {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("remediationAction"), ra, remediationActions()))
+	}
+
+	return append(allErrs, spec.NamespaceSelector.Validate(fldPath.Child("namespaceSelector"))...)
+}
+
+// Validate checks that the NamespaceSelector's LabelSelector and AnnotationSelector are
+// well-formed, and that each of the Include and Exclude patterns can be compiled according to the
+// MatchMode. All problems are returned, with paths relative to the given fldPath.
+func (sel NamespaceSelector) Validate(fldPath *field.Path) field.ErrorList {
+	allErrs := metav1validation.ValidateLabelSelector(
+		sel.LabelSelector, metav1validation.LabelSelectorValidationOptions{}, fldPath)
+	allErrs = append(allErrs,
+		validateAnnotationSelector(sel.AnnotationSelector, fldPath.Child("annotationSelector"))...)
+
+	return append(allErrs, validatePatterns(sel.MatchMode, sel.Include, sel.Exclude, fldPath)...)
+}
+
+// Validate checks that the Target's LabelSelector, AnnotationSelector, FieldSelector, owner and age
+// filters, FieldRequirements, Namespace, NamespaceSelector, and Expression are well-formed, and
+// that each of the Include and Exclude patterns can be compiled according to the MatchMode. All
+// problems are returned, with paths relative to the given fldPath.
+func (t Target) Validate(fldPath *field.Path) field.ErrorList {
+	allErrs := metav1validation.ValidateLabelSelector(
+		t.LabelSelector, metav1validation.LabelSelectorValidationOptions{}, fldPath)
+	allErrs = append(allErrs,
+		validateAnnotationSelector(t.AnnotationSelector, fldPath.Child("annotationSelector"))...)
+
+	if t.Namespace != "" {
+		for _, msg := range validation.IsDNS1123Label(t.Namespace) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("namespace"), t.Namespace, msg))
+		}
+	}
+
+	if t.NamespaceSelector != nil {
+		allErrs = append(allErrs, t.NamespaceSelector.Validate(fldPath.Child("namespaceSelector"))...)
+	}
+
+	if t.FieldSelector != "" {
+		if _, err := fields.ParseSelector(t.FieldSelector); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("fieldSelector"), t.FieldSelector, err.Error()))
+		}
+	}
+
+	allErrs = append(allErrs, validateOwnerFilters(t.Owner, t.NoOwner, t.MatchMode, fldPath)...)
+	allErrs = append(allErrs, validateAgeFilter(t.OlderThan, t.NewerThan, fldPath)...)
+
+	for i, req := range t.FieldRequirements {
+		allErrs = append(allErrs, req.Validate(fldPath.Child("fieldRequirements").Index(i))...)
+	}
+
+	if t.Expression != "" {
+		if _, err := compileExpression(t.Expression); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("expression"), t.Expression, err.Error()))
+		}
+	}
+
+	return append(allErrs, validatePatterns(t.MatchMode, t.Include, t.Exclude, fldPath)...)
+}
+
+// Validate checks the APIVersion and Kind of the TargetResource, as well as all of the fields of its
+// Target. All problems are returned, with paths relative to the given fldPath.
+func (tr TargetResource) Validate(fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if _, err := schema.ParseGroupVersion(tr.APIVersion); err != nil || tr.APIVersion == "" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("apiVersion"), tr.APIVersion,
+			"must be a group and version, like 'apps/v1', or just a version for the core group, like 'v1'"))
+	}
+
+	if tr.Kind == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("kind"), ""))
+	}
+
+	return append(allErrs, tr.Target.Validate(fldPath)...)
+}
+
+// severities returns the accepted Severity values. These must be kept in sync with the enum marker
+// on the Severity type, which the API server uses to validate the same field.
+func severities() []Severity {
+	return []Severity{"low", "Low", "medium", "Medium", "high", "High", "critical", "Critical"}
+}
+
+// remediationActions returns the accepted RemediationAction values, for error messages; validity is
+// decided by `IsEnforce` and `IsInform`. These must be kept in sync with the enum marker on the
+// RemediationAction type.
+func remediationActions() []RemediationAction {
+	return []RemediationAction{"Inform", "inform", "Enforce", "enforce"}
+}
+
+// validatePatterns compiles each of the include and exclude patterns according to the MatchMode,
+// returning an error for each one which is empty or malformed. If the MatchMode is not recognized,
+// only that error is returned, since the patterns can not be interpreted.
+func validatePatterns(mode MatchMode, include, exclude []NonEmptyString, fldPath *field.Path) field.ErrorList {
+	if validModes := []MatchMode{GlobMatchMode, RegexMatchMode}; mode != "" && !slices.Contains(validModes, mode) {
+		return field.ErrorList{field.NotSupported(fldPath.Child("matchMode"), mode, validModes)}
+	}
+
+	allErrs := field.ErrorList{}
+
+	for _, list := range []struct {
+		name     string
+		patterns []NonEmptyString
+	}{{"include", include}, {"exclude", exclude}} {
+		for i, pattern := range list.patterns {
+			idxPath := fldPath.Child(list.name).Index(i)
+
+			if pattern == "" {
+				allErrs = append(allErrs, field.Required(idxPath, "patterns must not be empty"))
+
+				continue
+			}
+
+			if err := compilePattern(mode, string(pattern)); err != nil {
+				allErrs = append(allErrs, field.Invalid(idxPath, pattern, err.Error()))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// compilePattern returns an error if the pattern is malformed, when interpreted according to the
+// MatchMode, which must be recognized.
+func compilePattern(mode MatchMode, pattern string) error {
+	if mode == RegexMatchMode {
+		_, err := compileAnchoredRegex(pattern)
+
+		return err
+	}
+
+	return checkGlobSyntax(pattern)
+}
+
+// checkGlobSyntax returns filepath.ErrBadPattern if the pattern is malformed, according to the
+// syntax of `filepath.Match`. This is needed because Match stops checking the pattern as soon as
+// part of it does not match the name, so no single name can be used to check the whole pattern.
+func checkGlobSyntax(pattern string) error {
+	for len(pattern) > 0 {
+		var err error
+
+		switch pattern[0] {
+		case '\\':
+			if len(pattern) == 1 {
+				return filepath.ErrBadPattern
+			}
+
+			_, size := utf8.DecodeRuneInString(pattern[1:])
+			pattern = pattern[1+size:]
+		case '[':
+			pattern, err = checkGlobClass(pattern[1:])
+			if err != nil {
+				return err
+			}
+		default:
+			_, size := utf8.DecodeRuneInString(pattern)
+			pattern = pattern[size:]
+		}
+	}
+
+	return nil
+}
+
+// checkGlobClass checks the character class at the start of the pattern, after its opening '[',
+// and returns the rest of the pattern after the closing ']'.
+func checkGlobClass(pattern string) (string, error) {
+	pattern = strings.TrimPrefix(pattern, "^")
+
+	for nrange := 0; ; nrange++ {
+		if len(pattern) > 0 && pattern[0] == ']' && nrange > 0 {
+			return pattern[1:], nil
+		}
+
+		var err error
+
+		pattern, err = checkGlobClassChar(pattern)
+		if err != nil {
+			return "", err
+		}
+
+		if len(pattern) > 0 && pattern[0] == '-' {
+			pattern, err = checkGlobClassChar(pattern[1:])
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+}
+
+// checkGlobClassChar checks the (possibly escaped) character at the start of the pattern, which is
+// inside a character class, and returns the rest of the pattern after it.
+func checkGlobClassChar(pattern string) (string, error) {
+	if len(pattern) == 0 || pattern[0] == '-' || pattern[0] == ']' {
+		return "", filepath.ErrBadPattern
+	}
+
+	if pattern[0] == '\\' {
+		pattern = pattern[1:]
+		if len(pattern) == 0 {
+			return "", filepath.ErrBadPattern
+		}
+	}
+
+	r, size := utf8.DecodeRuneInString(pattern)
+	if r == utf8.RuneError && size == 1 {
+		return "", filepath.ErrBadPattern
+	}
+
+	return pattern[size:], nil
+}
+
+// Validate checks that the FieldRequirement's Path is well-formed, and that its Values agree with
+// its Operator. All problems are returned, with paths relative to the given fldPath.
+func (req FieldRequirement) Validate(fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if slices.Contains(strings.Split(req.Path, "."), "") {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("path"), req.Path,
+			"must be keys separated by dots"))
+	}
+
+	switch req.Operator {
+	case FieldOpIn, FieldOpNotIn:
+		if len(req.Values) == 0 {
+			allErrs = append(allErrs, field.Required(fldPath.Child("values"),
+				"must be specified when the operator is In or NotIn"))
+		}
+	case FieldOpExists, FieldOpDoesNotExist:
+		if len(req.Values) != 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("values"),
+				"may not be specified when the operator is Exists or DoesNotExist"))
+		}
+	default:
+		validOps := []FieldOperator{FieldOpIn, FieldOpNotIn, FieldOpExists, FieldOpDoesNotExist}
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("operator"), req.Operator, validOps))
+	}
+
+	return allErrs
+}
@@ -0,0 +1,335 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// errorSummaries returns the type and path of each error, for easier comparisons.
+func errorSummaries(errs field.ErrorList) []string {
+	summaries := make([]string, len(errs))
+	for i, err := range errs {
+		summaries[i] = string(err.Type) + " " + err.Field
+	}
+
+	return summaries
+}
+
+// malformedAnnotationSelector returns an AnnotationSelector with an invalid key, and with invalid
+// values or operators in each of its expressions.
+func malformedAnnotationSelector() *metav1.LabelSelector {
+	return &metav1.LabelSelector{
+		MatchLabels: map[string]string{"bad key!": "foo", "good-key": "any value: is fine!"},
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "owner", Operator: metav1.LabelSelectorOpIn},
+			{Key: "owner", Operator: metav1.LabelSelectorOpDoesNotExist, Values: []string{"foo"}},
+			{Key: "owner", Operator: "Equals", Values: []string{"foo"}},
+		},
+	}
+}
+
+// checkCompileError verifies that the error from compiling wraps the wanted error (if any), and
+// starts with the wanted message.
+func checkCompileError(t *testing.T, name string, err error, wantErr error, wantMsg string) {
+	t.Helper()
+
+	if wantErr != nil && !errors.Is(err, wantErr) {
+		t.Errorf("Expected the compile error in test '%v' to wrap '%v', got '%v'", name, wantErr, err)
+	}
+
+	if err == nil || !strings.HasPrefix(err.Error(), wantMsg) {
+		t.Errorf("Expected a compile error starting with '%v' in test '%v', got '%v'", wantMsg, name, err)
+	}
+}
+
+func TestTargetValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		target     Target
+		want       []string
+		compileErr error
+		compileMsg string
+	}{
+		"empty target": {
+			target: Target{},
+			want:   []string{},
+		},
+		"valid target": {
+			target: Target{
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"sample": "foo"}},
+				Namespace:     "default",
+				NamespaceSelector: &NamespaceSelector{
+					Include:   []NonEmptyString{"kube-.*"},
+					MatchMode: RegexMatchMode,
+				},
+				Include:       []NonEmptyString{"f*", "b?r"},
+				Exclude:       []NonEmptyString{"[a-c]*"},
+				FieldSelector: "metadata.name!=foo",
+			},
+			want: []string{},
+		},
+		"malformed globs": {
+			target: Target{
+				Include: []NonEmptyString{"foo", "kube-[system", ""},
+				Exclude: []NonEmptyString{"[", "bar", "ba\\"},
+			},
+			want: []string{
+				"FieldValueInvalid spec.target.include[1]",
+				"FieldValueRequired spec.target.include[2]",
+				"FieldValueInvalid spec.target.exclude[0]",
+				"FieldValueInvalid spec.target.exclude[2]",
+			},
+		},
+		"malformed globs after a star": {
+			target: Target{
+				Include: []NonEmptyString{"a*b[", "web-*[", "a*[]]", "a*[a-z]x"},
+				Exclude: []NonEmptyString{"a*[^]", "x*\\", "a*[a-]", "*[\\]]"},
+			},
+			want: []string{
+				"FieldValueInvalid spec.target.include[0]",
+				"FieldValueInvalid spec.target.include[1]",
+				"FieldValueInvalid spec.target.include[2]",
+				"FieldValueInvalid spec.target.exclude[0]",
+				"FieldValueInvalid spec.target.exclude[1]",
+				"FieldValueInvalid spec.target.exclude[2]",
+			},
+		},
+		"malformed regular expressions": {
+			target: Target{
+				Include:   []NonEmptyString{"kube-(system", "[a-z]+"},
+				Exclude:   []NonEmptyString{"a**"},
+				MatchMode: RegexMatchMode,
+			},
+			want: []string{
+				"FieldValueInvalid spec.target.include[0]",
+				"FieldValueInvalid spec.target.exclude[0]",
+			},
+		},
+		"malformed field requirements": {
+			target: Target{FieldRequirements: []FieldRequirement{
+				{Path: "spec.type", Operator: FieldOpIn, Values: []string{"NodePort"}},
+				{Path: "spec..type", Operator: FieldOpNotIn},
+				{Path: "metadata.labels.app", Operator: FieldOpExists, Values: []string{"foo"}},
+				{Path: "spec.type", Operator: "Equals", Values: []string{"NodePort"}},
+			}},
+			want: []string{
+				"FieldValueInvalid spec.target.fieldRequirements[1].path",
+				"FieldValueRequired spec.target.fieldRequirements[1].values",
+				"FieldValueForbidden spec.target.fieldRequirements[2].values",
+				"FieldValueNotSupported spec.target.fieldRequirements[3].operator",
+			},
+			compileErr: ErrInvalidFieldRequirement,
+			compileMsg: "invalid field requirement: [fieldRequirements[1].path: " +
+				"Invalid value: \"spec..type\": must be keys separated by dots",
+		},
+		"field requirement with an unknown operator": {
+			target: Target{FieldRequirements: []FieldRequirement{
+				{Path: "spec.type", Operator: "Equals", Values: []string{"a"}},
+			}},
+			want:       []string{"FieldValueNotSupported spec.target.fieldRequirements[0].operator"},
+			compileErr: ErrInvalidFieldRequirement,
+			compileMsg: "invalid field requirement: fieldRequirements[0].operator: " +
+				"Unsupported value: \"Equals\": supported values: \"In\", \"NotIn\", \"Exists\", \"DoesNotExist\"",
+		},
+		"expression with a syntax error": {
+			target:     Target{Expression: `object.data.tier ==`},
+			want:       []string{"FieldValueInvalid spec.target.expression"},
+			compileMsg: "error compiling 'expression': ERROR: <input>:1:20: Syntax error",
+		},
+		"expression with an undeclared variable": {
+			target:     Target{Expression: `obj.data.tier == "gold"`},
+			want:       []string{"FieldValueInvalid spec.target.expression"},
+			compileMsg: "error compiling 'expression': ERROR: <input>:1:1: undeclared reference to 'obj'",
+		},
+		"expression which is not a bool": {
+			target:     Target{Expression: `"gold"`},
+			want:       []string{"FieldValueInvalid spec.target.expression"},
+			compileErr: ErrExpressionNotBool,
+			compileMsg: "error compiling 'expression': the expression must evaluate to a bool, not string",
+		},
+		"malformed annotation selector": {
+			target: Target{AnnotationSelector: malformedAnnotationSelector()},
+			want: []string{
+				"FieldValueInvalid spec.target.annotationSelector.matchLabels",
+				"FieldValueRequired spec.target.annotationSelector.matchExpressions[0].values",
+				"FieldValueForbidden spec.target.annotationSelector.matchExpressions[1].values",
+				"FieldValueNotSupported spec.target.annotationSelector.matchExpressions[2].operator",
+			},
+			compileErr: ErrInvalidAnnotationSelector,
+			compileMsg: "invalid annotation selector: ",
+		},
+		"owner and no owner": {
+			target:     Target{Owner: &OwnerSelector{Kind: "ReplicaSet"}, NoOwner: true},
+			want:       []string{"FieldValueForbidden spec.target.noOwner"},
+			compileErr: ErrInvalidOwnerFilter,
+			compileMsg: "invalid owner filter: noOwner: Forbidden: may not be set at the same time as the owner",
+		},
+		"malformed owner glob": {
+			target:     Target{Owner: &OwnerSelector{Name: "web-[a"}},
+			want:       []string{"FieldValueInvalid spec.target.owner.name"},
+			compileErr: ErrInvalidOwnerFilter,
+			compileMsg: "invalid owner filter: owner.name: Invalid value: \"web-[a\": syntax error in pattern",
+		},
+		"malformed owner glob after a star": {
+			target:     Target{Owner: &OwnerSelector{Name: "web-*["}},
+			want:       []string{"FieldValueInvalid spec.target.owner.name"},
+			compileErr: ErrInvalidOwnerFilter,
+			compileMsg: "invalid owner filter: owner.name: Invalid value: \"web-*[\": syntax error in pattern",
+		},
+		"malformed owner regex": {
+			target:     Target{Owner: &OwnerSelector{Name: "web-(a"}, MatchMode: RegexMatchMode},
+			want:       []string{"FieldValueInvalid spec.target.owner.name"},
+			compileErr: ErrInvalidOwnerFilter,
+			compileMsg: "invalid owner filter: owner.name: Invalid value: \"web-(a\": " +
+				"error parsing regexp: missing closing ): `web-(a`",
+		},
+		"negative age durations": {
+			target: Target{
+				OlderThan: &metav1.Duration{Duration: -time.Hour},
+				NewerThan: &metav1.Duration{Duration: -time.Minute},
+			},
+			want: []string{
+				"FieldValueInvalid spec.target.olderThan",
+				"FieldValueInvalid spec.target.newerThan",
+			},
+			compileErr: ErrInvalidAge,
+			compileMsg: "invalid age filter: [olderThan: Invalid value: \"-1h0m0s\": must not be negative",
+		},
+		"empty age window": {
+			target: Target{
+				OlderThan: &metav1.Duration{Duration: time.Hour},
+				NewerThan: &metav1.Duration{Duration: time.Hour},
+			},
+			want:       []string{"FieldValueInvalid spec.target.newerThan"},
+			compileErr: ErrInvalidAge,
+			compileMsg: "invalid age filter: newerThan: Invalid value: \"1h0m0s\": must be longer than olderThan",
+		},
+		"unknown match mode": {
+			target: Target{Include: []NonEmptyString{"["}, MatchMode: "Fuzzy"},
+			want:   []string{"FieldValueNotSupported spec.target.matchMode"},
+		},
+		"every other field is malformed": {
+			target: Target{
+				LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key:      "sample",
+					Operator: metav1.LabelSelectorOpExists,
+					Values:   []string{"foo"},
+				}}},
+				Namespace: "Not_A_Namespace",
+				NamespaceSelector: &NamespaceSelector{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"bad key!": "foo"}},
+					Exclude:       []NonEmptyString{"foo", "bar", "kube-[system"},
+				},
+				FieldSelector: "data.tier",
+			},
+			want: []string{
+				"FieldValueForbidden spec.target.matchExpressions[0].values",
+				"FieldValueInvalid spec.target.namespace",
+				"FieldValueInvalid spec.target.namespaceSelector.matchLabels",
+				"FieldValueInvalid spec.target.namespaceSelector.exclude[2]",
+				"FieldValueInvalid spec.target.fieldSelector",
+			},
+		},
+	}
+
+	for name, tcase := range tests {
+		got := tcase.target.Validate(field.NewPath("spec", "target"))
+
+		if diff := cmp.Diff(tcase.want, errorSummaries(got)); diff != "" {
+			t.Errorf("Mismatch in test '%v': %v", name, diff)
+		}
+
+		if tcase.compileErr != nil || tcase.compileMsg != "" {
+			_, err := tcase.target.Compile()
+			checkCompileError(t, name, err, tcase.compileErr, tcase.compileMsg)
+		}
+	}
+}
+
+func TestPolicyCoreSpecValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		spec       PolicyCoreSpec
+		want       []string
+		compileErr error
+		compileMsg string
+	}{
+		"valid spec": {
+			spec: PolicyCoreSpec{
+				Severity:          "Low",
+				RemediationAction: "enforce",
+				NamespaceSelector: NamespaceSelector{Include: []NonEmptyString{"*"}},
+			},
+			want: []string{},
+		},
+		"empty spec": {
+			spec: PolicyCoreSpec{},
+			want: []string{},
+		},
+		"every field is malformed": {
+			spec: PolicyCoreSpec{
+				Severity:          "severe",
+				RemediationAction: "fix",
+				NamespaceSelector: NamespaceSelector{
+					Include: []NonEmptyString{"a[", "b"},
+					Exclude: []NonEmptyString{"foo", "bar", "kube-[system"},
+				},
+			},
+			want: []string{
+				"FieldValueNotSupported spec.severity",
+				"FieldValueNotSupported spec.remediationAction",
+				"FieldValueInvalid spec.namespaceSelector.include[0]",
+				"FieldValueInvalid spec.namespaceSelector.exclude[2]",
+			},
+		},
+		"malformed annotation selector": {
+			spec: PolicyCoreSpec{
+				NamespaceSelector: NamespaceSelector{AnnotationSelector: malformedAnnotationSelector()},
+			},
+			want: []string{
+				"FieldValueInvalid spec.namespaceSelector.annotationSelector.matchLabels",
+				"FieldValueRequired spec.namespaceSelector.annotationSelector.matchExpressions[0].values",
+				"FieldValueForbidden spec.namespaceSelector.annotationSelector.matchExpressions[1].values",
+				"FieldValueNotSupported spec.namespaceSelector.annotationSelector.matchExpressions[2].operator",
+			},
+			compileErr: ErrInvalidAnnotationSelector,
+			compileMsg: "invalid annotation selector: ",
+		},
+	}
+
+	for name, tcase := range tests {
+		got := tcase.spec.Validate(field.NewPath("spec"))
+
+		if diff := cmp.Diff(tcase.want, errorSummaries(got)); diff != "" {
+			t.Errorf("Mismatch in test '%v': %v", name, diff)
+		}
+
+		if tcase.compileErr != nil || tcase.compileMsg != "" {
+			_, err := tcase.spec.NamespaceSelector.Compile()
+			checkCompileError(t, name, err, tcase.compileErr, tcase.compileMsg)
+		}
+	}
+}
+
+func TestValidateErrorMessage(t *testing.T) {
+	t.Parallel()
+
+	sel := NamespaceSelector{Exclude: []NonEmptyString{"foo", "bar", "kube-[system"}}
+
+	got := sel.Validate(field.NewPath("spec", "namespaceSelector")).ToAggregate().Error()
+	want := `spec.namespaceSelector.exclude[2]: Invalid value: "kube-[system": syntax error in pattern`
+
+	if got != want {
+		t.Errorf("Expected '%v', got '%v'", want, got)
+	}
+}
@@ -8,9 +8,70 @@ package v1beta1
 
 import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComplianceHistoryEntry) DeepCopyInto(out *ComplianceHistoryEntry) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComplianceHistoryEntry.
+func (in *ComplianceHistoryEntry) DeepCopy() *ComplianceHistoryEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(ComplianceHistoryEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FieldRequirement) DeepCopyInto(out *FieldRequirement) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FieldRequirement.
+func (in *FieldRequirement) DeepCopy() *FieldRequirement {
+	if in == nil {
+		return nil
+	}
+	out := new(FieldRequirement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceSelectionDrift) DeepCopyInto(out *NamespaceSelectionDrift) {
+	*out = *in
+	if in.Added != nil {
+		in, out := &in.Added, &out.Added
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Removed != nil {
+		in, out := &in.Removed, &out.Removed
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceSelectionDrift.
+func (in *NamespaceSelectionDrift) DeepCopy() *NamespaceSelectionDrift {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceSelectionDrift)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NamespaceSelector) DeepCopyInto(out *NamespaceSelector) {
 	*out = *in
@@ -19,6 +80,11 @@ func (in *NamespaceSelector) DeepCopyInto(out *NamespaceSelector) {
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.AnnotationSelector != nil {
+		in, out := &in.AnnotationSelector, &out.AnnotationSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Include != nil {
 		in, out := &in.Include, &out.Include
 		*out = make([]NonEmptyString, len(*in))
@@ -41,6 +107,21 @@ func (in *NamespaceSelector) DeepCopy() *NamespaceSelector {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OwnerSelector) DeepCopyInto(out *OwnerSelector) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnerSelector.
+func (in *OwnerSelector) DeepCopy() *OwnerSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(OwnerSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PolicyCore) DeepCopyInto(out *PolicyCore) {
 	*out = *in
@@ -94,6 +175,18 @@ func (in *PolicyCoreStatus) DeepCopyInto(out *PolicyCoreStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ComplianceHistory != nil {
+		in, out := &in.ComplianceHistory, &out.ComplianceHistory
+		*out = make([]ComplianceHistoryEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SelectedNamespaces != nil {
+		in, out := &in.SelectedNamespaces, &out.SelectedNamespaces
+		*out = new(SelectedNamespaces)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyCoreStatus.
@@ -106,6 +199,26 @@ func (in *PolicyCoreStatus) DeepCopy() *PolicyCoreStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SelectedNamespaces) DeepCopyInto(out *SelectedNamespaces) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SelectedNamespaces.
+func (in *SelectedNamespaces) DeepCopy() *SelectedNamespaces {
+	if in == nil {
+		return nil
+	}
+	out := new(SelectedNamespaces)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Target) DeepCopyInto(out *Target) {
 	*out = *in
@@ -114,6 +227,16 @@ func (in *Target) DeepCopyInto(out *Target) {
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.AnnotationSelector != nil {
+		in, out := &in.AnnotationSelector, &out.AnnotationSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(NamespaceSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Include != nil {
 		in, out := &in.Include, &out.Include
 		*out = make([]NonEmptyString, len(*in))
@@ -124,6 +247,28 @@ func (in *Target) DeepCopyInto(out *Target) {
 		*out = make([]NonEmptyString, len(*in))
 		copy(*out, *in)
 	}
+	if in.Owner != nil {
+		in, out := &in.Owner, &out.Owner
+		*out = new(OwnerSelector)
+		**out = **in
+	}
+	if in.OlderThan != nil {
+		in, out := &in.OlderThan, &out.OlderThan
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.NewerThan != nil {
+		in, out := &in.NewerThan, &out.NewerThan
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.FieldRequirements != nil {
+		in, out := &in.FieldRequirements, &out.FieldRequirements
+		*out = make([]FieldRequirement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Target.
@@ -135,3 +280,40 @@ func (in *Target) DeepCopy() *Target {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in TargetList) DeepCopyInto(out *TargetList) {
+	{
+		in := &in
+		*out = make(TargetList, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetList.
+func (in TargetList) DeepCopy() TargetList {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetList)
+	in.DeepCopyInto(out)
+	return *out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetResource) DeepCopyInto(out *TargetResource) {
+	*out = *in
+	in.Target.DeepCopyInto(&out.Target)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetResource.
+func (in *TargetResource) DeepCopy() *TargetResource {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetResource)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,119 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package targetwatch
+
+import (
+	"context"
+
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	nucleusv1beta1 "open-cluster-management.io/governance-policy-nucleus/api/v1beta1"
+)
+
+// NamespaceHandler is a controller-runtime EventHandler for Namespaces, which enqueues the
+// policies whose NamespaceSelector matches a created or deleted namespace, or whose result changes
+// when a namespace is updated (for example, when it is relabeled). The selectors are evaluated
+// against the namespaces in the events, without any extra API calls.
+//
+// For example, it could be used in a policy controller's setup like this:
+//
+//	ctrl.NewControllerManagedBy(mgr).
+//		For(&FakePolicy{}).
+//		Watches(&corev1.Namespace{}, &targetwatch.NamespaceHandler{...}).
+//		Complete(r)
+type NamespaceHandler struct {
+	// ListPolicies returns the policies which should be checked for each event. To avoid API calls,
+	// this should usually list from a cache, for example the manager's client.
+	ListPolicies func(ctx context.Context) ([]nucleusv1beta1.PolicyLike, error)
+
+	// NamespaceSelector returns the NamespaceSelector of the policy. Policies using the
+	// PolicyCoreSpec will usually return `Spec.NamespaceSelector`.
+	NamespaceSelector func(policy nucleusv1beta1.PolicyLike) nucleusv1beta1.NamespaceSelector
+
+	// Options are used when evaluating the selectors. They should match the options the policies
+	// use with GetNamespaces, for example ExcludeTerminatingNamespaces.
+	Options []nucleusv1beta1.MatchOption
+}
+
+// Run a compile-time check to ensure NamespaceHandler implements handler.EventHandler.
+var _ handler.EventHandler = &NamespaceHandler{}
+
+// Create enqueues the policies which select the new namespace.
+func (h *NamespaceHandler) Create(
+	ctx context.Context, evt event.CreateEvent, queue workqueue.RateLimitingInterface,
+) {
+	h.enqueueAffected(ctx, queue, nil, evt.Object)
+}
+
+// Update enqueues the policies which selected the namespace before or after the update, but not
+// both.
+func (h *NamespaceHandler) Update(
+	ctx context.Context, evt event.UpdateEvent, queue workqueue.RateLimitingInterface,
+) {
+	h.enqueueAffected(ctx, queue, evt.ObjectOld, evt.ObjectNew)
+}
+
+// Delete enqueues the policies which selected the deleted namespace.
+func (h *NamespaceHandler) Delete(
+	ctx context.Context, evt event.DeleteEvent, queue workqueue.RateLimitingInterface,
+) {
+	h.enqueueAffected(ctx, queue, evt.Object, nil)
+}
+
+// Generic enqueues the policies which select the namespace.
+func (h *NamespaceHandler) Generic(
+	ctx context.Context, evt event.GenericEvent, queue workqueue.RateLimitingInterface,
+) {
+	h.enqueueAffected(ctx, queue, nil, evt.Object)
+}
+
+// enqueueAffected enqueues each policy whose NamespaceSelector matches exactly one of the old and
+// new namespaces; either of them can be nil, which matches nothing. When a selector can not be
+// evaluated, the policy is enqueued so that its reconcile can report the problem.
+func (h *NamespaceHandler) enqueueAffected(
+	ctx context.Context, queue workqueue.RateLimitingInterface, oldNS, newNS client.Object,
+) {
+	logr := log.FromContext(ctx)
+
+	policies, err := h.ListPolicies(ctx)
+	if err != nil {
+		logr.Error(err, "Failed to list the policies to check against a namespace event")
+
+		return
+	}
+
+	for _, policy := range policies {
+		sel, err := h.NamespaceSelector(policy).Compile()
+		if err != nil {
+			logr.Error(err, "Failed to compile the NamespaceSelector of a policy",
+				"policy", client.ObjectKeyFromObject(policy))
+
+			queue.Add(reconcile.Request{NamespacedName: client.ObjectKeyFromObject(policy)})
+
+			continue
+		}
+
+		oldMatch, oldErr := namespaceMatches(sel, oldNS, h.Options)
+		newMatch, newErr := namespaceMatches(sel, newNS, h.Options)
+
+		if oldErr != nil || newErr != nil || oldMatch != newMatch {
+			queue.Add(reconcile.Request{NamespacedName: client.ObjectKeyFromObject(policy)})
+		}
+	}
+}
+
+// namespaceMatches returns whether the compiled selector matches the namespace, which may be nil.
+func namespaceMatches(
+	sel *nucleusv1beta1.CompiledNamespaceSelector, ns client.Object, opts []nucleusv1beta1.MatchOption,
+) (bool, error) {
+	if ns == nil {
+		return false, nil
+	}
+
+	return sel.Matches(ns, opts...)
+}
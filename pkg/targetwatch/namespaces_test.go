@@ -0,0 +1,183 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package targetwatch
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllertest"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	nucleusv1beta1 "open-cluster-management.io/governance-policy-nucleus/api/v1beta1"
+	fakev1beta1 "open-cluster-management.io/governance-policy-nucleus/test/fakepolicy/api/v1beta1"
+)
+
+func samplePolicies() []nucleusv1beta1.PolicyLike {
+	selectors := map[string]nucleusv1beta1.NamespaceSelector{
+		"by-name": {Include: []nucleusv1beta1.NonEmptyString{"team-*"}},
+		"by-label": {LabelSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"env": "prod"},
+		}},
+		"everything": {Include: []nucleusv1beta1.NonEmptyString{"*"}},
+		"empty":      {},
+		"malformed":  {Include: []nucleusv1beta1.NonEmptyString{"team-["}},
+	}
+
+	policies := make([]nucleusv1beta1.PolicyLike, 0, len(selectors))
+
+	for name, sel := range selectors {
+		policy := &fakev1beta1.FakePolicy{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "policies"}}
+		policy.Spec.NamespaceSelector = sel
+
+		policies = append(policies, policy)
+	}
+
+	return policies
+}
+
+func namespace(name string, labels map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func TestNamespaceHandler(t *testing.T) {
+	t.Parallel()
+
+	h := &NamespaceHandler{
+		ListPolicies: func(_ context.Context) ([]nucleusv1beta1.PolicyLike, error) {
+			return samplePolicies(), nil
+		},
+		NamespaceSelector: func(policy nucleusv1beta1.PolicyLike) nucleusv1beta1.NamespaceSelector {
+			return policy.(*fakev1beta1.FakePolicy).Spec.NamespaceSelector //nolint:forcetypeassert // test
+		},
+	}
+
+	tests := map[string]struct {
+		send func(q workqueue.RateLimitingInterface)
+		want []string
+	}{
+		"create a namespace matching by name": {
+			send: func(q workqueue.RateLimitingInterface) {
+				h.Create(context.TODO(), event.CreateEvent{Object: namespace("team-a", nil)}, q)
+			},
+			want: []string{"by-name", "everything", "malformed"},
+		},
+		"delete a namespace matching by label": {
+			send: func(q workqueue.RateLimitingInterface) {
+				h.Delete(context.TODO(), event.DeleteEvent{
+					Object: namespace("default", map[string]string{"env": "prod"}),
+				}, q)
+			},
+			want: []string{"by-label", "everything", "malformed"},
+		},
+		"relabel a namespace": {
+			send: func(q workqueue.RateLimitingInterface) {
+				h.Update(context.TODO(), event.UpdateEvent{
+					ObjectOld: namespace("team-b", map[string]string{"env": "dev"}),
+					ObjectNew: namespace("team-b", map[string]string{"env": "prod"}),
+				}, q)
+			},
+			want: []string{"by-label", "malformed"},
+		},
+		"update a namespace without changing its labels": {
+			send: func(q workqueue.RateLimitingInterface) {
+				h.Update(context.TODO(), event.UpdateEvent{
+					ObjectOld: namespace("team-b", map[string]string{"env": "prod"}),
+					ObjectNew: namespace("team-b", map[string]string{"env": "prod"}),
+				}, q)
+			},
+			want: []string{"malformed"},
+		},
+		"generic event": {
+			send: func(q workqueue.RateLimitingInterface) {
+				h.Generic(context.TODO(), event.GenericEvent{Object: namespace("other", nil)}, q)
+			},
+			want: []string{"everything", "malformed"},
+		},
+	}
+
+	less := func(a, b string) bool { return a < b }
+
+	for name, tcase := range tests {
+		q := &controllertest.Queue{Interface: workqueue.New()}
+
+		tcase.send(q)
+
+		got := make([]string, 0)
+
+		for q.Len() > 0 {
+			item, _ := q.Get()
+
+			req, ok := item.(reconcile.Request)
+			if !ok {
+				t.Fatalf("Unexpected item in the queue in test '%v': %v", name, item)
+			}
+
+			if req.Namespace != "policies" {
+				t.Errorf("Unexpected namespace in the request in test '%v': %v", name, req)
+			}
+
+			got = append(got, req.Name)
+			q.Done(item)
+		}
+
+		if diff := cmp.Diff(tcase.want, got, cmpopts.SortSlices(less)); diff != "" {
+			t.Errorf("Mismatch in test '%v': %v", name, diff)
+		}
+	}
+}
+
+func TestNamespaceHandlerListError(t *testing.T) {
+	t.Parallel()
+
+	h := &NamespaceHandler{
+		ListPolicies: func(_ context.Context) ([]nucleusv1beta1.PolicyLike, error) {
+			return nil, errors.New("list failed")
+		},
+	}
+
+	q := &controllertest.Queue{Interface: workqueue.New()}
+
+	h.Create(context.TODO(), event.CreateEvent{Object: namespace("team-a", nil)}, q)
+
+	if q.Len() != 0 {
+		t.Errorf("Expected nothing to be enqueued when the policies can not be listed, got %v", q.Len())
+	}
+}
+
+func TestNamespaceHandlerOptions(t *testing.T) {
+	t.Parallel()
+
+	h := &NamespaceHandler{
+		ListPolicies: func(_ context.Context) ([]nucleusv1beta1.PolicyLike, error) {
+			policy := &fakev1beta1.FakePolicy{ObjectMeta: metav1.ObjectMeta{Name: "teams", Namespace: "policies"}}
+			policy.Spec.NamespaceSelector = nucleusv1beta1.NamespaceSelector{
+				Include: []nucleusv1beta1.NonEmptyString{"team-*"},
+			}
+
+			return []nucleusv1beta1.PolicyLike{policy}, nil
+		},
+		NamespaceSelector: func(policy nucleusv1beta1.PolicyLike) nucleusv1beta1.NamespaceSelector {
+			return policy.(*fakev1beta1.FakePolicy).Spec.NamespaceSelector //nolint:forcetypeassert // test
+		},
+		Options: []nucleusv1beta1.MatchOption{nucleusv1beta1.ExcludeTerminatingNamespaces()},
+	}
+
+	terminating := namespace("team-a", nil)
+	terminating.Status.Phase = corev1.NamespaceTerminating
+
+	q := &controllertest.Queue{Interface: workqueue.New()}
+
+	h.Update(context.TODO(), event.UpdateEvent{ObjectOld: namespace("team-a", nil), ObjectNew: terminating}, q)
+
+	if q.Len() != 1 {
+		t.Errorf("Expected the policy to be enqueued when its namespace starts terminating, got %v", q.Len())
+	}
+}
@@ -0,0 +1,504 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package targetwatch
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	nucleusv1beta1 "open-cluster-management.io/governance-policy-nucleus/api/v1beta1"
+)
+
+var (
+	// ErrAlreadyStarted is returned by Start when the Watcher has already been started.
+	ErrAlreadyStarted = errors.New("the watcher has already been started")
+	// ErrNotStarted is returned by Stop and Resync when the Watcher has not been started.
+	ErrNotStarted = errors.New("the watcher has not been started")
+	// ErrStopped is returned by Start, Stop, and Resync after the Watcher has been stopped.
+	ErrStopped = errors.New("the watcher has been stopped")
+	// ErrAgeFilterUnsupported is returned by New when the Target has an OlderThan or NewerThan. Those
+	// matches change as time passes, without any event for the Watcher to react to.
+	ErrAgeFilterUnsupported = errors.New("the olderThan and newerThan filters can not be watched")
+)
+
+// Delta describes how the set of objects matched by a Target changed. Added objects started
+// matching, Removed objects stopped matching (or were deleted), and Updated objects still match but
+// were modified. Removed objects are given in their last known state. The objects are shared with
+// the Watcher (and usually with the informer's cache), so they must not be modified.
+type Delta struct {
+	Added   []client.Object
+	Removed []client.Object
+	Updated []client.Object
+}
+
+// Empty returns whether the Delta does not describe any changes.
+func (d Delta) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Updated) == 0
+}
+
+// Watcher keeps the set of objects matched by a Target current, based on the events from an
+// informer for the kind, and reports every change to that set as a Delta. When the Target has a
+// NamespaceSelector, the namespaces are also watched, and the whole set is re-evaluated when a
+// namespace starts or stops being selected.
+//
+// The objects are handled as unstructured, so the kind does not need to be registered in the
+// scheme, and the Target's FieldRequirements and Expression can see their full content.
+type Watcher struct {
+	compiled   *nucleusv1beta1.CompiledTarget
+	nsSelector *nucleusv1beta1.CompiledNamespaceSelector
+	gvk        schema.GroupVersionKind
+	notify     func(Delta)
+	opts       []nucleusv1beta1.MatchOption
+
+	mu                 sync.Mutex
+	started            bool
+	stopped            bool
+	pending            []Delta
+	delivering         bool
+	reader             client.Reader
+	logger             logr.Logger
+	matches            map[types.NamespacedName]client.Object
+	selectedNamespaces []string
+	registrations      []registration
+}
+
+// registration remembers an event handler added to an informer, so that it can be removed.
+type registration struct {
+	informer cache.Informer
+	handle   toolscache.ResourceEventHandlerRegistration
+}
+
+// New compiles the Target, and returns a Watcher for objects of the given kind. The notify
+// function is called with each non-empty Delta, one at a time and in the order the changes were
+// seen. It is called without the Watcher's lock held, so it may call Matches, and the next Delta
+// waits for it to return. The MatchOptions are used whenever the Target is evaluated. Targets
+// with an OlderThan or NewerThan are rejected with an ErrAgeFilterUnsupported, since their matches
+// would go stale as objects age.
+func New(
+	target nucleusv1beta1.Target,
+	gvk schema.GroupVersionKind,
+	notify func(Delta),
+	opts ...nucleusv1beta1.MatchOption,
+) (*Watcher, error) {
+	if target.OlderThan != nil || target.NewerThan != nil {
+		return nil, ErrAgeFilterUnsupported
+	}
+
+	compiled, err := target.Compile()
+	if err != nil {
+		return nil, err
+	}
+
+	var nsSelector *nucleusv1beta1.CompiledNamespaceSelector
+
+	if target.NamespaceSelector != nil {
+		nsSelector, err = target.NamespaceSelector.Compile()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Watcher{
+		compiled:   compiled,
+		nsSelector: nsSelector,
+		gvk:        gvk,
+		notify:     notify,
+		opts:       opts,
+		matches:    map[types.NamespacedName]client.Object{},
+	}, nil
+}
+
+// Start adds event handlers to the informers for the kind (and for namespaces, when the Target has
+// a NamespaceSelector), and finds the initial matches with the reader. In a controller, both the
+// informers and the reader will usually be the manager's cache. The informers are not started
+// here. The context is also used for the re-evaluations after namespace changes, so it should last
+// as long as the Watcher. If the initial matches can not be found, the error is returned but the
+// Watcher is still started, so Resync can be tried again later.
+func (w *Watcher) Start(ctx context.Context, informers cache.Informers, reader client.Reader) error {
+	w.mu.Lock()
+
+	if err := w.startLocked(ctx, informers, reader); err != nil {
+		w.mu.Unlock()
+
+		return err
+	}
+
+	delta, err := w.resyncLocked(ctx)
+	w.reportAndUnlock(delta)
+
+	return err
+}
+
+// startLocked adds the event handlers, and marks the Watcher as started. The lock must be held.
+func (w *Watcher) startLocked(ctx context.Context, informers cache.Informers, reader client.Reader) error {
+	if w.stopped {
+		return ErrStopped
+	}
+
+	if w.started {
+		return ErrAlreadyStarted
+	}
+
+	w.reader = reader
+	w.logger = log.FromContext(ctx).WithValues("gvk", w.gvk.String())
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(w.gvk)
+
+	informer, err := informers.GetInformer(ctx, obj)
+	if err != nil {
+		return err
+	}
+
+	if err := w.addHandler(informer, toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    w.handleUpsert,
+		UpdateFunc: func(_, newObj interface{}) { w.handleUpsert(newObj) },
+		DeleteFunc: w.handleDelete,
+	}); err != nil {
+		return err
+	}
+
+	if w.nsSelector != nil {
+		nsInformer, err := informers.GetInformer(ctx, &corev1.Namespace{})
+		if err != nil {
+			return errors.Join(err, w.removeHandlers())
+		}
+
+		resync := func(oldNS, newNS interface{}) {
+			if !w.selectionChanged(oldNS, newNS) {
+				return
+			}
+
+			if err := w.resync(ctx); err != nil && !errors.Is(err, ErrStopped) {
+				w.logger.Error(err, "Failed to re-evaluate the matches after a namespace change")
+			}
+		}
+
+		if err := w.addHandler(nsInformer, toolscache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { resync(nil, obj) },
+			UpdateFunc: resync,
+			DeleteFunc: func(obj interface{}) { resync(obj, nil) },
+		}); err != nil {
+			return errors.Join(err, w.removeHandlers())
+		}
+	}
+
+	w.started = true
+
+	return nil
+}
+
+// Stop removes the event handlers added by Start, and ignores any events which were already on
+// their way. The last matches are kept for Matches, but the Watcher can not be started or resynced
+// again: Start, Stop, and Resync all return an ErrStopped after this.
+func (w *Watcher) Stop() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopped {
+		return ErrStopped
+	}
+
+	if !w.started {
+		return ErrNotStarted
+	}
+
+	w.stopped = true
+
+	return w.removeHandlers()
+}
+
+// Resync lists all of the objects of the kind (and the namespaces, when the Target has a
+// NamespaceSelector) with the reader, and reports any differences from the current matches. This
+// is done automatically when namespaces change, but it can also be used to recover from errors.
+func (w *Watcher) Resync(ctx context.Context) error {
+	return w.resync(ctx)
+}
+
+// Matches returns copies of the objects currently matched by the Target, sorted by namespace and
+// name. The copies can be modified without affecting the Watcher.
+func (w *Watcher) Matches() []client.Object {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	keys := make([]types.NamespacedName, 0, len(w.matches))
+	for key := range w.matches {
+		keys = append(keys, key)
+	}
+
+	slices.SortFunc(keys, func(a, b types.NamespacedName) int {
+		return strings.Compare(a.String(), b.String())
+	})
+
+	matches := make([]client.Object, 0, len(keys))
+
+	for _, key := range keys {
+		if copied, ok := w.matches[key].DeepCopyObject().(client.Object); ok {
+			matches = append(matches, copied)
+		}
+	}
+
+	return matches
+}
+
+// NotifyChannel returns a notify function for a Watcher, which sends a GenericEvent for the given
+// policy to the channel whenever its Target's matches change. The channel can be used with a
+// `source.Channel` in a controller, in order to reconcile the policy. The send blocks until the
+// channel has room for the event, and the Watcher's later notifications wait for it, so the channel
+// should be buffered. In particular, if the Watcher is started before the controller is reading
+// from the channel, it needs room for the initial event, or Start may not return.
+func NotifyChannel(ch chan<- event.GenericEvent, policy client.Object) func(Delta) {
+	return func(_ Delta) {
+		ch <- event.GenericEvent{Object: policy}
+	}
+}
+
+func (w *Watcher) addHandler(informer cache.Informer, handler toolscache.ResourceEventHandler) error {
+	handle, err := informer.AddEventHandler(handler)
+	if err != nil {
+		return err
+	}
+
+	w.registrations = append(w.registrations, registration{informer: informer, handle: handle})
+
+	return nil
+}
+
+func (w *Watcher) removeHandlers() error {
+	var errs []error
+
+	for _, reg := range w.registrations {
+		if err := reg.informer.RemoveEventHandler(reg.handle); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	w.registrations = nil
+
+	return errors.Join(errs...)
+}
+
+// handleUpsert evaluates an added or updated object, and reports how it changed the matches.
+func (w *Watcher) handleUpsert(obj interface{}) {
+	cObj, ok := obj.(client.Object)
+	if !ok {
+		return
+	}
+
+	w.mu.Lock()
+
+	if w.stopped {
+		w.mu.Unlock()
+
+		return
+	}
+
+	key := client.ObjectKeyFromObject(cObj)
+	prev, wasMatched := w.matches[key]
+	delta := Delta{}
+
+	switch matched := w.evaluate(cObj); {
+	case matched && !wasMatched:
+		w.matches[key] = cObj
+		delta.Added = append(delta.Added, cObj)
+	case matched && wasMatched:
+		w.matches[key] = cObj
+
+		if prev.GetResourceVersion() != cObj.GetResourceVersion() {
+			delta.Updated = append(delta.Updated, cObj)
+		}
+	case !matched && wasMatched:
+		delete(w.matches, key)
+		delta.Removed = append(delta.Removed, prev)
+	}
+
+	w.reportAndUnlock(delta)
+}
+
+// handleDelete reports the removal of a deleted object, if it was matched.
+func (w *Watcher) handleDelete(obj interface{}) {
+	if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	cObj, ok := obj.(client.Object)
+	if !ok {
+		return
+	}
+
+	w.mu.Lock()
+
+	if w.stopped {
+		w.mu.Unlock()
+
+		return
+	}
+
+	key := client.ObjectKeyFromObject(cObj)
+	delta := Delta{}
+
+	if prev, wasMatched := w.matches[key]; wasMatched {
+		delete(w.matches, key)
+		delta.Removed = append(delta.Removed, prev)
+	}
+
+	w.reportAndUnlock(delta)
+}
+
+// selectionChanged returns whether a namespace event changes which namespaces are selected, based
+// on whether the NamespaceSelector matches the old and new namespaces; either of them can be nil,
+// which matches nothing. When the selector can not be evaluated, it is treated as a change, so that
+// the resync can report the problem.
+func (w *Watcher) selectionChanged(oldNS, newNS interface{}) bool {
+	oldMatch, oldErr := namespaceMatches(w.nsSelector, namespaceObject(oldNS), w.opts)
+	newMatch, newErr := namespaceMatches(w.nsSelector, namespaceObject(newNS), w.opts)
+
+	return oldErr != nil || newErr != nil || oldMatch != newMatch
+}
+
+// namespaceObject returns the namespace from an informer event, unwrapping a tombstone, or nil if
+// there is not one.
+//
+//nolint:ireturn // the namespace is only used through the client.Object interface
+func namespaceObject(obj interface{}) client.Object {
+	if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	ns, ok := obj.(client.Object)
+	if !ok {
+		return nil
+	}
+
+	return ns
+}
+
+func (w *Watcher) resync(ctx context.Context) error {
+	w.mu.Lock()
+
+	if w.stopped {
+		w.mu.Unlock()
+
+		return ErrStopped
+	}
+
+	if !w.started {
+		w.mu.Unlock()
+
+		return ErrNotStarted
+	}
+
+	delta, err := w.resyncLocked(ctx)
+	w.reportAndUnlock(delta)
+
+	return err
+}
+
+// resyncLocked re-evaluates every object of the kind, replacing the current matches, and returns
+// the differences. The lock must be held.
+func (w *Watcher) resyncLocked(ctx context.Context) (Delta, error) {
+	if w.nsSelector != nil {
+		namespaces, err := w.nsSelector.GetNamespaces(ctx, w.reader, w.opts...)
+		if err != nil {
+			return Delta{}, err
+		}
+
+		w.selectedNamespaces = namespaces
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(w.gvk.GroupVersion().WithKind(w.gvk.Kind + "List"))
+
+	if err := w.reader.List(ctx, list); err != nil {
+		return Delta{}, err
+	}
+
+	matches := make(map[types.NamespacedName]client.Object, len(w.matches))
+	delta := Delta{}
+
+	for i := range list.Items {
+		obj := &list.Items[i]
+
+		if !w.evaluate(obj) {
+			continue
+		}
+
+		key := client.ObjectKeyFromObject(obj)
+		matches[key] = obj
+
+		if prev, wasMatched := w.matches[key]; !wasMatched {
+			delta.Added = append(delta.Added, obj)
+		} else if prev.GetResourceVersion() != obj.GetResourceVersion() {
+			delta.Updated = append(delta.Updated, obj)
+		}
+	}
+
+	for key, prev := range w.matches {
+		if _, stillMatched := matches[key]; !stillMatched {
+			delta.Removed = append(delta.Removed, prev)
+		}
+	}
+
+	w.matches = matches
+
+	return delta, nil
+}
+
+// evaluate returns whether the Target matches the object. Errors are logged, and the object is
+// treated as not matching. The lock must be held.
+func (w *Watcher) evaluate(obj client.Object) bool {
+	explanation, err := w.compiled.ExplainObject(obj, w.selectedNamespaces, w.opts...)
+	if err != nil {
+		w.logger.Error(err, "Failed to evaluate the Target against an object",
+			"namespace", obj.GetNamespace(), "name", obj.GetName())
+
+		return false
+	}
+
+	return explanation.Included
+}
+
+// reportAndUnlock queues the Delta if it is not empty, and releases the lock. If no other goroutine
+// is already delivering Deltas, this one calls the notify function for each queued Delta, in the
+// order they were found, until the queue is empty. The notify function is called without the lock
+// held, so it can call Matches while other events are handled. The lock must be held.
+func (w *Watcher) reportAndUnlock(delta Delta) {
+	if !delta.Empty() && w.notify != nil {
+		w.pending = append(w.pending, delta)
+	}
+
+	if w.delivering {
+		w.mu.Unlock()
+
+		return
+	}
+
+	w.delivering = true
+
+	for len(w.pending) > 0 {
+		next := w.pending[0]
+		w.pending = w.pending[1:]
+
+		w.mu.Unlock()
+		w.notify(next)
+		w.mu.Lock()
+	}
+
+	w.delivering = false
+
+	w.mu.Unlock()
+}
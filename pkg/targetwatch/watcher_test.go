@@ -0,0 +1,519 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package targetwatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	nucleusv1beta1 "open-cluster-management.io/governance-policy-nucleus/api/v1beta1"
+)
+
+var cmGVK = schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+
+func configMap(ns, name, rv string, labels map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       ns,
+			ResourceVersion: rv,
+			Labels:          labels,
+		},
+	}
+}
+
+// deltaNames summarizes a Delta as the "namespace/name" of its objects, with a prefix of '+' for
+// added objects, '-' for removed objects, and '~' for updated objects.
+func deltaNames(deltas []Delta) []string {
+	names := make([]string, 0)
+
+	for _, d := range deltas {
+		for prefix, objs := range map[string][]client.Object{"+": d.Added, "-": d.Removed, "~": d.Updated} {
+			for _, obj := range objs {
+				names = append(names, prefix+obj.GetNamespace()+"/"+obj.GetName())
+			}
+		}
+	}
+
+	return names
+}
+
+func matchNames(w *Watcher) []string {
+	names := make([]string, 0)
+	for _, obj := range w.Matches() {
+		names = append(names, obj.GetNamespace()+"/"+obj.GetName())
+	}
+
+	return names
+}
+
+func TestWatcherEvents(t *testing.T) {
+	t.Parallel()
+
+	target := nucleusv1beta1.Target{
+		LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"watched": "true"}},
+		Exclude:       []nucleusv1beta1.NonEmptyString{"ignored"},
+	}
+
+	initial := []runtime.Object{
+		configMap("default", "foo", "1", map[string]string{"watched": "true"}),
+		configMap("default", "bar", "1", nil),
+	}
+
+	var deltas []Delta
+
+	w, err := New(target, cmGVK, func(d Delta) { deltas = append(deltas, d) })
+	if err != nil {
+		t.Fatalf("Unexpected error creating the watcher: '%v'", err)
+	}
+
+	informers := &informertest.FakeInformers{}
+	reader := fake.NewClientBuilder().WithRuntimeObjects(initial...).Build()
+
+	if err := w.Start(context.TODO(), informers, reader); err != nil {
+		t.Fatalf("Unexpected error starting the watcher: '%v'", err)
+	}
+
+	if err := w.Start(context.TODO(), informers, reader); !errors.Is(err, ErrAlreadyStarted) {
+		t.Errorf("Expected an ErrAlreadyStarted, got '%v'", err)
+	}
+
+	informer, err := informers.FakeInformerForKind(context.TODO(), cmGVK)
+	if err != nil {
+		t.Fatalf("Unexpected error getting the fake informer: '%v'", err)
+	}
+
+	steps := []struct {
+		desc        string
+		event       func()
+		wantDelta   []string
+		wantMatches []string
+	}{{
+		desc:        "initial list",
+		event:       func() {},
+		wantDelta:   []string{"+default/foo"},
+		wantMatches: []string{"default/foo"},
+	}, {
+		desc: "a matching object is added",
+		event: func() {
+			informer.Add(configMap("kube-public", "baz", "2", map[string]string{"watched": "true"}))
+		},
+		wantDelta:   []string{"+kube-public/baz"},
+		wantMatches: []string{"default/foo", "kube-public/baz"},
+	}, {
+		desc: "an excluded object is added",
+		event: func() {
+			informer.Add(configMap("default", "ignored", "3", map[string]string{"watched": "true"}))
+		},
+		wantDelta:   []string{},
+		wantMatches: []string{"default/foo", "kube-public/baz"},
+	}, {
+		desc: "an object is labeled to match",
+		event: func() {
+			informer.Update(configMap("default", "bar", "1", nil),
+				configMap("default", "bar", "4", map[string]string{"watched": "true"}))
+		},
+		wantDelta:   []string{"+default/bar"},
+		wantMatches: []string{"default/bar", "default/foo", "kube-public/baz"},
+	}, {
+		desc: "a matched object is modified",
+		event: func() {
+			informer.Update(configMap("default", "foo", "1", map[string]string{"watched": "true"}),
+				configMap("default", "foo", "5", map[string]string{"watched": "true", "other": "x"}))
+		},
+		wantDelta:   []string{"~default/foo"},
+		wantMatches: []string{"default/bar", "default/foo", "kube-public/baz"},
+	}, {
+		desc: "an unchanged object is resynced by the informer",
+		event: func() {
+			informer.Update(configMap("default", "foo", "5", map[string]string{"watched": "true"}),
+				configMap("default", "foo", "5", map[string]string{"watched": "true"}))
+		},
+		wantDelta:   []string{},
+		wantMatches: []string{"default/bar", "default/foo", "kube-public/baz"},
+	}, {
+		desc: "an object is relabeled to not match",
+		event: func() {
+			informer.Update(configMap("default", "foo", "5", map[string]string{"watched": "true"}),
+				configMap("default", "foo", "6", map[string]string{"watched": "false"}))
+		},
+		wantDelta:   []string{"-default/foo"},
+		wantMatches: []string{"default/bar", "kube-public/baz"},
+	}, {
+		desc: "a matched object is deleted",
+		event: func() {
+			informer.Delete(configMap("kube-public", "baz", "2", map[string]string{"watched": "true"}))
+		},
+		wantDelta:   []string{"-kube-public/baz"},
+		wantMatches: []string{"default/bar"},
+	}, {
+		desc: "an object which was not matched is deleted",
+		event: func() {
+			informer.Delete(configMap("default", "ignored", "3", map[string]string{"watched": "true"}))
+		},
+		wantDelta:   []string{},
+		wantMatches: []string{"default/bar"},
+	}}
+
+	seen := 0
+
+	for _, step := range steps {
+		step.event()
+
+		if diff := cmp.Diff(step.wantDelta, deltaNames(deltas[seen:])); diff != "" {
+			t.Errorf("Mismatch in the delta after '%v': %v", step.desc, diff)
+		}
+
+		seen = len(deltas)
+
+		if diff := cmp.Diff(step.wantMatches, matchNames(w)); diff != "" {
+			t.Errorf("Mismatch in the matches after '%v': %v", step.desc, diff)
+		}
+	}
+
+	if err := w.Stop(); err != nil {
+		t.Errorf("Unexpected error stopping the watcher: '%v'", err)
+	}
+}
+
+func TestWatcherNamespaceSelector(t *testing.T) {
+	t.Parallel()
+
+	objs := []runtime.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}},
+		configMap("team-a", "app", "1", nil),
+		configMap("other", "app", "1", nil),
+		configMap("team-b", "app", "1", nil),
+	}
+
+	target := nucleusv1beta1.Target{
+		NamespaceSelector: &nucleusv1beta1.NamespaceSelector{Include: []nucleusv1beta1.NonEmptyString{"team-*"}},
+	}
+
+	var deltas []Delta
+
+	w, err := New(target, cmGVK, func(d Delta) { deltas = append(deltas, d) })
+	if err != nil {
+		t.Fatalf("Unexpected error creating the watcher: '%v'", err)
+	}
+
+	informers := &informertest.FakeInformers{}
+	reader := fake.NewClientBuilder().WithRuntimeObjects(objs...).Build()
+
+	if err := w.Start(context.TODO(), informers, reader); err != nil {
+		t.Fatalf("Unexpected error starting the watcher: '%v'", err)
+	}
+
+	if diff := cmp.Diff([]string{"+team-a/app"}, deltaNames(deltas)); diff != "" {
+		t.Errorf("Mismatch in the initial delta: %v", diff)
+	}
+
+	nsInformer, err := informers.FakeInformerFor(context.TODO(), &corev1.Namespace{})
+	if err != nil {
+		t.Fatalf("Unexpected error getting the fake namespace informer: '%v'", err)
+	}
+
+	newNS := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b"}}
+	if err := reader.Create(context.TODO(), newNS); err != nil {
+		t.Fatalf("Unexpected error creating a namespace: '%v'", err)
+	}
+
+	seen := len(deltas)
+
+	nsInformer.Add(newNS)
+
+	if diff := cmp.Diff([]string{"+team-b/app"}, deltaNames(deltas[seen:])); diff != "" {
+		t.Errorf("Mismatch in the delta after a namespace was added: %v", diff)
+	}
+
+	if diff := cmp.Diff([]string{"team-a/app", "team-b/app"}, matchNames(w)); diff != "" {
+		t.Errorf("Mismatch in the matches after a namespace was added: %v", diff)
+	}
+
+	// This is only in the reader, so it will only be found if the watcher resyncs.
+	if err := reader.Create(context.TODO(), configMap("team-a", "late", "", nil)); err != nil {
+		t.Fatalf("Unexpected error creating a configmap: '%v'", err)
+	}
+
+	seen = len(deltas)
+
+	relabel := func(ns *corev1.Namespace) *corev1.Namespace {
+		relabeled := ns.DeepCopy()
+		relabeled.Labels = map[string]string{"relabeled": "true"}
+
+		return relabeled
+	}
+
+	other := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}}
+	nsInformer.Update(other, relabel(other))
+	nsInformer.Update(newNS, relabel(newNS))
+
+	if diff := cmp.Diff([]string{}, deltaNames(deltas[seen:])); diff != "" {
+		t.Errorf("Mismatch in the delta after namespaces were updated without changing the selection: %v", diff)
+	}
+
+	if diff := cmp.Diff([]string{"team-a/app", "team-b/app"}, matchNames(w)); diff != "" {
+		t.Errorf("Mismatch in the matches after namespaces were updated without changing the selection: %v", diff)
+	}
+
+	if err := reader.Delete(context.TODO(), newNS); err != nil {
+		t.Fatalf("Unexpected error deleting a namespace: '%v'", err)
+	}
+
+	nsInformer.Delete(newNS)
+
+	wantDelta := []string{"+team-a/late", "-team-b/app"}
+	if diff := cmp.Diff(wantDelta, deltaNames(deltas[seen:]), cmpopts.SortSlices(lessString)); diff != "" {
+		t.Errorf("Mismatch in the delta after a namespace was deleted: %v", diff)
+	}
+}
+
+func lessString(a, b string) bool {
+	return a < b
+}
+
+func TestWatcherNotifyOutsideLock(t *testing.T) {
+	t.Parallel()
+
+	var (
+		w      *Watcher
+		called []string
+	)
+
+	// Matches takes the watcher's lock, so this would deadlock if notify was called while holding it.
+	w, err := New(nucleusv1beta1.Target{}, cmGVK, func(_ Delta) { called = matchNames(w) })
+	if err != nil {
+		t.Fatalf("Unexpected error creating the watcher: '%v'", err)
+	}
+
+	informers := &informertest.FakeInformers{}
+	reader := fake.NewClientBuilder().WithRuntimeObjects(configMap("default", "foo", "1", nil)).Build()
+
+	if err := w.Start(context.TODO(), informers, reader); err != nil {
+		t.Fatalf("Unexpected error starting the watcher: '%v'", err)
+	}
+
+	if diff := cmp.Diff([]string{"default/foo"}, called); diff != "" {
+		t.Errorf("Mismatch in the matches seen by the notify function: %v", diff)
+	}
+}
+
+func TestWatcherConcurrentNotify(t *testing.T) {
+	t.Parallel()
+
+	var (
+		w        *Watcher
+		mu       sync.Mutex
+		deltas   []Delta
+		inNotify atomic.Int32
+		overlap  atomic.Bool
+	)
+
+	// The notify function calls Matches while other goroutines are handling events, which would
+	// deadlock if a handler could hold the watcher's lock while waiting on a notification. The sleep
+	// gives the other goroutines time to take the lock before Matches is called.
+	w, err := New(nucleusv1beta1.Target{}, cmGVK, func(d Delta) {
+		if inNotify.Add(1) > 1 {
+			overlap.Store(true)
+		}
+		defer inNotify.Add(-1)
+
+		time.Sleep(time.Millisecond)
+
+		_ = w.Matches()
+
+		mu.Lock()
+		deltas = append(deltas, d)
+		mu.Unlock()
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error creating the watcher: '%v'", err)
+	}
+
+	informers := &informertest.FakeInformers{}
+	reader := fake.NewClientBuilder().Build()
+
+	if err := w.Start(context.TODO(), informers, reader); err != nil {
+		t.Fatalf("Unexpected error starting the watcher: '%v'", err)
+	}
+
+	const goroutines, perGoroutine = 4, 50
+
+	var wg sync.WaitGroup
+
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+
+		go func(g int) {
+			defer wg.Done()
+
+			for i := 0; i < perGoroutine; i++ {
+				w.handleUpsert(configMap("default", fmt.Sprintf("cm-%d-%d", g, i), "1", nil))
+			}
+		}(g)
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timed out waiting for the events to be handled")
+	}
+
+	if overlap.Load() {
+		t.Error("The notify function was called concurrently")
+	}
+
+	mu.Lock()
+	got := deltaNames(deltas)
+	mu.Unlock()
+
+	want := make([]string, 0, goroutines*perGoroutine)
+
+	for g := 0; g < goroutines; g++ {
+		for i := 0; i < perGoroutine; i++ {
+			want = append(want, fmt.Sprintf("+default/cm-%d-%d", g, i))
+		}
+	}
+
+	if diff := cmp.Diff(want, got, cmpopts.SortSlices(lessString)); diff != "" {
+		t.Errorf("Mismatch in the reported deltas: %v", diff)
+	}
+}
+
+func TestWatcherErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(nucleusv1beta1.Target{Expression: "object.metadata.name =="}, cmGVK, nil)
+	if err == nil {
+		t.Errorf("Expected an error creating a watcher with a malformed Target")
+	}
+
+	_, err = New(nucleusv1beta1.Target{OlderThan: &metav1.Duration{Duration: time.Hour}}, cmGVK, nil)
+	if !errors.Is(err, ErrAgeFilterUnsupported) {
+		t.Errorf("Expected an ErrAgeFilterUnsupported for a Target with an age filter, got '%v'", err)
+	}
+
+	w, err := New(nucleusv1beta1.Target{}, cmGVK, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error creating the watcher: '%v'", err)
+	}
+
+	if err := w.Stop(); !errors.Is(err, ErrNotStarted) {
+		t.Errorf("Expected an ErrNotStarted from Stop, got '%v'", err)
+	}
+
+	if err := w.Resync(context.TODO()); !errors.Is(err, ErrNotStarted) {
+		t.Errorf("Expected an ErrNotStarted from Resync, got '%v'", err)
+	}
+}
+
+func TestWatcherStop(t *testing.T) {
+	t.Parallel()
+
+	deltas := make([]Delta, 0)
+
+	w, err := New(nucleusv1beta1.Target{}, cmGVK, func(d Delta) { deltas = append(deltas, d) })
+	if err != nil {
+		t.Fatalf("Unexpected error creating the watcher: '%v'", err)
+	}
+
+	informers := &informertest.FakeInformers{}
+	reader := fake.NewClientBuilder().WithRuntimeObjects(configMap("default", "foo", "1", nil)).Build()
+
+	if err := w.Start(context.TODO(), informers, reader); err != nil {
+		t.Fatalf("Unexpected error starting the watcher: '%v'", err)
+	}
+
+	if err := w.Stop(); err != nil {
+		t.Fatalf("Unexpected error stopping the watcher: '%v'", err)
+	}
+
+	// Events which were already on their way when the watcher was stopped should be ignored.
+	w.handleUpsert(configMap("default", "bar", "1", nil))
+	w.handleDelete(configMap("default", "foo", "1", nil))
+
+	if diff := cmp.Diff([]string{"+default/foo"}, deltaNames(deltas)); diff != "" {
+		t.Errorf("Mismatch in the reported deltas after stopping: %v", diff)
+	}
+
+	if diff := cmp.Diff([]string{"default/foo"}, matchNames(w)); diff != "" {
+		t.Errorf("Mismatch in the matches kept after stopping: %v", diff)
+	}
+
+	if err := w.Start(context.TODO(), informers, reader); !errors.Is(err, ErrStopped) {
+		t.Errorf("Expected an ErrStopped from Start, got '%v'", err)
+	}
+
+	if err := w.Stop(); !errors.Is(err, ErrStopped) {
+		t.Errorf("Expected an ErrStopped from Stop, got '%v'", err)
+	}
+
+	if err := w.Resync(context.TODO()); !errors.Is(err, ErrStopped) {
+		t.Errorf("Expected an ErrStopped from Resync, got '%v'", err)
+	}
+}
+
+func TestWatcherMatchesAreCopies(t *testing.T) {
+	t.Parallel()
+
+	w, err := New(nucleusv1beta1.Target{}, cmGVK, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error creating the watcher: '%v'", err)
+	}
+
+	informers := &informertest.FakeInformers{}
+	reader := fake.NewClientBuilder().WithRuntimeObjects(configMap("default", "foo", "1", nil)).Build()
+
+	if err := w.Start(context.TODO(), informers, reader); err != nil {
+		t.Fatalf("Unexpected error starting the watcher: '%v'", err)
+	}
+
+	w.Matches()[0].SetLabels(map[string]string{"changed": "true"})
+
+	if labels := w.Matches()[0].GetLabels(); len(labels) != 0 {
+		t.Errorf("Expected the watcher's object to be unchanged, got labels '%v'", labels)
+	}
+}
+
+func TestNotifyChannel(t *testing.T) {
+	t.Parallel()
+
+	policy := configMap("default", "policy", "1", nil)
+	ch := make(chan event.GenericEvent, 1)
+
+	NotifyChannel(ch, policy)(Delta{Added: []client.Object{configMap("default", "foo", "1", nil)}})
+
+	select {
+	case ev := <-ch:
+		if ev.Object != policy {
+			t.Errorf("Expected an event for the policy, got one for '%v'", ev.Object.GetName())
+		}
+	default:
+		t.Errorf("Expected an event to be sent to the channel")
+	}
+}
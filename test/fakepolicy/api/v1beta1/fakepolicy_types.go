@@ -15,6 +15,13 @@ type FakePolicySpec struct {
 	// TargetConfigMaps defines the ConfigMaps which should be examined by this policy
 	TargetConfigMaps nucleusv1beta1.Target `json:"targetConfigMaps,omitempty"`
 
+	// TargetConfigMapsUnion defines additional ConfigMaps which should be examined by this policy,
+	// matching any of the Targets in the list
+	TargetConfigMapsUnion nucleusv1beta1.TargetList `json:"targetConfigMapsUnion,omitempty"`
+
+	// TargetResource defines resources of any kind which should be examined by this policy
+	TargetResource *nucleusv1beta1.TargetResource `json:"targetResource,omitempty"`
+
 	// DesiredConfigMapName - if this name is not found, the policy will report a violation
 	DesiredConfigMapName string `json:"desiredConfigMapName,omitempty"`
 
@@ -56,7 +63,7 @@ func (f FakePolicy) ComplianceState() nucleusv1beta1.ComplianceState {
 }
 
 func (f FakePolicy) ComplianceMessage() string {
-	idx, compCond := f.Status.GetCondition("Compliant")
+	idx, compCond := f.Status.GetCondition(nucleusv1beta1.ConditionTypeCompliant)
 	if idx == -1 {
 		return ""
 	}
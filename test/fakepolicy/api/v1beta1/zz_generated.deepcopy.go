@@ -8,6 +8,7 @@ package v1beta1
 
 import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	apiv1beta1 "open-cluster-management.io/governance-policy-nucleus/api/v1beta1"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -74,6 +75,18 @@ func (in *FakePolicySpec) DeepCopyInto(out *FakePolicySpec) {
 	*out = *in
 	in.PolicyCoreSpec.DeepCopyInto(&out.PolicyCoreSpec)
 	in.TargetConfigMaps.DeepCopyInto(&out.TargetConfigMaps)
+	if in.TargetConfigMapsUnion != nil {
+		in, out := &in.TargetConfigMapsUnion, &out.TargetConfigMapsUnion
+		*out = make(apiv1beta1.TargetList, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TargetResource != nil {
+		in, out := &in.TargetResource, &out.TargetResource
+		*out = new(apiv1beta1.TargetResource)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FakePolicySpec.
@@ -4,11 +4,12 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"slices"
 
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -52,7 +53,7 @@ func (r *FakePolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 	policy := &fakev1beta1.FakePolicy{}
 	if err := r.Get(ctx, req.NamespacedName, policy); err != nil {
-		if errors.IsNotFound(err) {
+		if k8sErrors.IsNotFound(err) {
 			logr.Info("Request object not found, probably deleted")
 
 			return ctrl.Result{}, nil
@@ -66,26 +67,17 @@ func (r *FakePolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	cmFound := r.doSelections(ctx, policy)
 
 	policy.Status.SelectionComplete = true
+	generationChanged := policy.Status.SetObservedGeneration(policy.Generation)
 
-	complianceCondition := metav1.Condition{
-		Type:    "Compliant",
-		Status:  metav1.ConditionTrue,
-		Reason:  "Found",
-		Message: "the desired configmap was found",
-	}
-
-	policy.Status.ComplianceState = nucleusv1beta1.Compliant
+	var changed bool
 
-	if !cmFound {
-		complianceCondition.Status = metav1.ConditionFalse
-		complianceCondition.Reason = "NotFound"
-		complianceCondition.Message = "the desired configmap was missing"
-		policy.Status.ComplianceState = nucleusv1beta1.NonCompliant
+	if cmFound {
+		changed = policy.Status.SetCompliant("Found", "the desired configmap was found")
+	} else {
+		changed = policy.Status.SetNonCompliant("NotFound", "the desired configmap was missing")
 	}
 
-	changed := policy.Status.UpdateCondition(complianceCondition)
-
-	if !changed {
+	if !changed && !generationChanged {
 		logr.Info("No change; no compliance event to emit")
 
 		return ctrl.Result{}, nil
@@ -97,6 +89,12 @@ func (r *FakePolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
+	if !changed {
+		logr.Info("Only the observed generation changed; no compliance event to emit")
+
+		return ctrl.Result{}, nil
+	}
+
 	emitter := compliance.K8sEmitter{
 		Client: r.Client,
 	}
@@ -152,6 +150,8 @@ func (r *FakePolicyReconciler) doSelections(
 		slices.Sort(selectedNamespaces)
 
 		nsCond.Message = fmt.Sprintf("%v", selectedNamespaces)
+
+		policy.Status.SetSelectedNamespaces(selectedNamespaces)
 	}
 
 	policy.Status.UpdateCondition(nsCond)
@@ -168,7 +168,8 @@ func (r *FakePolicyReconciler) doSelections(
 		Resource: "configmaps",
 	})
 
-	dynamicMatchedCMs, err := policy.Spec.TargetConfigMaps.GetMatchesDynamic(ctx, cmIface)
+	dynamicMatchedCMs, err := policy.Spec.TargetConfigMaps.GetMatchesDynamic(ctx, cmIface,
+		nucleusv1beta1.WithNamespaceReader(r.Client))
 	if err != nil {
 		logr.Error(err, "Failed to GetMatchesDynamic for the TargetConfigMaps",
 			"target", policy.Spec.TargetConfigMaps)
@@ -195,15 +196,7 @@ func (r *FakePolicyReconciler) doSelections(
 		Reason: "Done",
 	}
 
-	var list nucleusv1beta1.ResourceList
-
-	if policy.Spec.TargetUsingReflection {
-		list = &nucleusv1alpha1.ReflectiveResourceList{ClientList: &corev1.ConfigMapList{}}
-	} else {
-		list = &configMapResList{}
-	}
-
-	clientMatchedCMs, err := policy.Spec.TargetConfigMaps.GetMatches(ctx, r.Client, list)
+	clientCMs, err := r.clientSelection(ctx, policy)
 	if err != nil {
 		logr.Error(err, "Failed to GetMatches for the TargetConfigMaps",
 			"target", policy.Spec.TargetConfigMaps)
@@ -212,44 +205,141 @@ func (r *FakePolicyReconciler) doSelections(
 		clientCond.Reason = "ErrorMatching"
 		clientCond.Message = err.Error()
 	} else {
-		clientCMs := make([]string, len(clientMatchedCMs))
-		for i, cm := range dynamicMatchedCMs {
-			clientCMs[i] = cm.GetNamespace() + "/" + cm.GetName()
+		clientCMNames := make([]string, len(clientCMs))
+		for i, cm := range clientCMs {
+			clientCMNames[i] = cm.GetNamespace() + "/" + cm.GetName()
 
 			if cm.GetName() == policy.Spec.DesiredConfigMapName {
 				configMapFound = true
 			}
 		}
 
-		slices.Sort(clientCMs)
+		slices.Sort(clientCMNames)
 
-		clientCond.Message = fmt.Sprintf("%v", clientCMs)
+		clientCond.Message = fmt.Sprintf("%v", clientCMNames)
 	}
 
 	policy.Status.UpdateCondition(clientCond)
 
+	if len(policy.Spec.TargetConfigMapsUnion) != 0 {
+		policy.Status.UpdateCondition(r.unionSelection(ctx, policy))
+	} else {
+		policy.Status.RemoveCondition("UnionSelection")
+	}
+
+	if policy.Spec.TargetResource != nil {
+		policy.Status.UpdateCondition(r.resourceSelection(ctx, policy))
+	} else {
+		policy.Status.RemoveCondition("ResourceSelection")
+	}
+
 	return configMapFound
 }
 
-type configMapResList struct {
-	corev1.ConfigMapList
+// clientSelection gets the ConfigMaps matched by the policy's TargetConfigMaps, either through the
+// ReflectiveResourceList or through the generic typed API, depending on the policy.
+func (r *FakePolicyReconciler) clientSelection(
+	ctx context.Context, policy *fakev1beta1.FakePolicy,
+) ([]client.Object, error) {
+	if policy.Spec.TargetUsingReflection {
+		list := &nucleusv1alpha1.ReflectiveResourceList{ClientList: &corev1.ConfigMapList{}}
+
+		return policy.Spec.TargetConfigMaps.GetMatches(ctx, r.Client, list)
+	}
+
+	cms, err := nucleusv1beta1.GetTypedMatches(ctx, policy.Spec.TargetConfigMaps, r.Client,
+		func(l *corev1.ConfigMapList) []corev1.ConfigMap { return l.Items })
+	if err != nil {
+		return nil, err
+	}
+
+	objs := make([]client.Object, len(cms))
+	for i, cm := range cms {
+		objs[i] = cm
+	}
+
+	return objs, nil
 }
 
-// Run a compile-time check to ensure configMapResList implements ResourceList.
-var _ nucleusv1beta1.ResourceList = (*configMapResList)(nil)
+// unionSelection returns a condition describing the ConfigMaps matched by the policy's
+// TargetConfigMapsUnion, using only their metadata.
+func (r *FakePolicyReconciler) unionSelection(
+	ctx context.Context, policy *fakev1beta1.FakePolicy,
+) metav1.Condition {
+	unionCond := metav1.Condition{
+		Type:   "UnionSelection",
+		Status: metav1.ConditionTrue,
+		Reason: "Done",
+	}
+
+	cmGVK := schema.GroupVersionKind{Group: "", Version: "v1", Kind: "ConfigMap"}
+
+	unionMatchedCMs, err := policy.Spec.TargetConfigMapsUnion.GetMatchesMetadata(ctx, r.Client, cmGVK)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to GetMatchesMetadata for the TargetConfigMapsUnion",
+			"targets", policy.Spec.TargetConfigMapsUnion)
+
+		unionCond.Status = metav1.ConditionFalse
+		unionCond.Reason = "ErrorUnionMatching"
+		unionCond.Message = err.Error()
+
+		return unionCond
+	}
 
-func (l *configMapResList) Items() ([]client.Object, error) {
-	items := make([]client.Object, len(l.ConfigMapList.Items))
-	for i := range l.ConfigMapList.Items {
-		items[i] = &l.ConfigMapList.Items[i]
+	unionCMs := make([]string, len(unionMatchedCMs))
+	for i, cm := range unionMatchedCMs {
+		unionCMs[i] = cm.GetNamespace() + "/" + cm.GetName()
 	}
 
-	return items, nil
+	slices.Sort(unionCMs)
+
+	unionCond.Message = fmt.Sprintf("%v", unionCMs)
+
+	return unionCond
 }
 
-//nolint:ireturn // the ResourceList interface requires this interface return
-func (l *configMapResList) ObjectList() client.ObjectList {
-	return &l.ConfigMapList
+// resourceSelection returns a condition describing the resources matched by the policy's
+// TargetResource, whose kind is resolved with the client's RESTMapper.
+func (r *FakePolicyReconciler) resourceSelection(
+	ctx context.Context, policy *fakev1beta1.FakePolicy,
+) metav1.Condition {
+	resCond := metav1.Condition{
+		Type:   "ResourceSelection",
+		Status: metav1.ConditionTrue,
+		Reason: "Done",
+	}
+
+	matches, err := policy.Spec.TargetResource.GetMatches(ctx, r.RESTMapper(), r.DynamicClient,
+		nucleusv1beta1.WithNamespaceReader(r.Client))
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to GetMatches for the TargetResource",
+			"target", policy.Spec.TargetResource)
+
+		resCond.Status = metav1.ConditionFalse
+		resCond.Reason = "ErrorResourceMatching"
+		resCond.Message = err.Error()
+
+		var notFound *nucleusv1beta1.KindNotFoundError
+		if errors.As(err, &notFound) {
+			resCond.Reason = "KindNotFound"
+		}
+
+		return resCond
+	}
+
+	names := make([]string, len(matches))
+	for i, obj := range matches {
+		names[i] = obj.GetName()
+		if obj.GetNamespace() != "" {
+			names[i] = obj.GetNamespace() + "/" + obj.GetName()
+		}
+	}
+
+	slices.Sort(names)
+
+	resCond.Message = fmt.Sprintf("%v", names)
+
+	return resCond
 }
 
 // SetupWithManager sets up the controller with the Manager.
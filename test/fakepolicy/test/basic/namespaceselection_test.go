@@ -26,8 +26,9 @@ var _ = Describe("FakePolicy NamespaceSelection", Ordered, func() {
 		By("Creating sample namespaces")
 		for _, ns := range sampleNamespaces {
 			nsObj := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
-				Name:   ns,
-				Labels: map[string]string{"sample": ns},
+				Name:        ns,
+				Labels:      map[string]string{"sample": ns},
+				Annotations: map[string]string{"sample-owner": "https://example.com/" + ns},
 			}}
 			Expect(tk.CleanlyCreate(ctx, nsObj)).To(Succeed())
 		}
@@ -66,6 +67,10 @@ var _ = Describe("FakePolicy NamespaceSelection", Ordered, func() {
 					g.Expect(cond.Message).To(Equal(selErr))
 				} else {
 					g.Expect(cond.Message).To(Equal(fmt.Sprintf("%v", desiredMatches)))
+
+					g.Expect(foundPolicy.Status.SelectedNamespaces).NotTo(BeNil())
+					g.Expect(foundPolicy.Status.SelectedNamespaces.Count).To(BeEquivalentTo(len(desiredMatches)))
+					g.Expect(foundPolicy.Status.NamespaceSelectionDrift(desiredMatches).Changed).To(BeFalse())
 				}
 			}).Should(Succeed())
 		},
@@ -88,6 +93,22 @@ var _ = Describe("FakePolicy NamespaceSelection", Ordered, func() {
 			Include: []nucleusv1beta1.NonEmptyString{"kube-[system"},
 		}, []string{}, "error parsing 'include' pattern 'kube-[system': syntax error in pattern"),
 
+		// Testing with regular expressions
+		Entry("include namespaces with a regex", nucleusv1beta1.NamespaceSelector{
+			Include:   []nucleusv1beta1.NonEmptyString{"fa[kz]e|goo"},
+			MatchMode: nucleusv1beta1.RegexMatchMode,
+		}, []string{"fake", "faze", "goo"}, ""),
+		Entry("exclude namespaces with a regex", nucleusv1beta1.NamespaceSelector{
+			Include:   []nucleusv1beta1.NonEmptyString{".*"},
+			Exclude:   []nucleusv1beta1.NonEmptyString{"kube-.*", "f.*"},
+			MatchMode: nucleusv1beta1.RegexMatchMode,
+		}, []string{"default", "goo"}, ""),
+		Entry("error if a regex include entry is malformed", nucleusv1beta1.NamespaceSelector{
+			Include:   []nucleusv1beta1.NonEmptyString{"kube-(system"},
+			MatchMode: nucleusv1beta1.RegexMatchMode,
+		}, []string{}, "error parsing 'include' pattern 'kube-(system': "+
+			"error parsing regexp: missing closing ): `kube-(system`"),
+
 		// Testing with label selector
 		Entry("select by a label existing", nucleusv1beta1.NamespaceSelector{
 			LabelSelector: &metav1.LabelSelector{
@@ -144,6 +165,34 @@ var _ = Describe("FakePolicy NamespaceSelection", Ordered, func() {
 		}, []string{}, "values: Invalid value: []string{\"foo\"}: "+
 			"values set must be empty for exists and does not exist"),
 
+		// Testing with annotation selector
+		Entry("select by an annotation matching a specific value", nucleusv1beta1.NamespaceSelector{
+			Include: []nucleusv1beta1.NonEmptyString{"*"},
+			AnnotationSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"sample-owner": "https://example.com/foo",
+				},
+			},
+		}, []string{"foo"}, ""),
+		Entry("exclude namespaces by an annotation", nucleusv1beta1.NamespaceSelector{
+			Include: []nucleusv1beta1.NonEmptyString{"*"},
+			AnnotationSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key:      "sample-owner",
+					Operator: metav1.LabelSelectorOpNotIn,
+					Values:   []string{"https://example.com/foo", "https://example.com/kube-one"},
+				}},
+			},
+		}, []string{"default", "kube-node-lease", "kube-public", "kube-system", "goo", "fake", "faze"}, ""),
+		Entry("an annotation selector alone matches nothing", nucleusv1beta1.NamespaceSelector{
+			AnnotationSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key:      "sample-owner",
+					Operator: metav1.LabelSelectorOpExists,
+				}},
+			},
+		}, []string{}, ""),
+
 		// Various flavors of "nil" - when left unset, or specifically set to nil
 		Entry("all nil fields", nucleusv1beta1.NamespaceSelector{
 			LabelSelector: &metav1.LabelSelector{
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -43,9 +44,24 @@ var _ = Describe("FakePolicy TargetConfigMaps", func() {
 					Name:      name,
 					Namespace: ns,
 					Labels:    map[string]string{"sample": name},
+					Annotations: map[string]string{
+						"sample-owner": "https://example.com/" + name,
+					},
 				},
 				Data: map[string]string{"foo": "bar"},
 			}
+
+			if name == "goo" {
+				// The owner does not need to exist, since there is no garbage collector in the test
+				// environment.
+				cmObj.OwnerReferences = []metav1.OwnerReference{{
+					APIVersion: "apps/v1",
+					Kind:       "ReplicaSet",
+					Name:       "web-5d8f7",
+					UID:        "4c1d5d8a-6a5b-4ad1-9d0c-1f1f8e2c3a7b",
+				}}
+			}
+
 			Expect(tk.CleanlyCreate(ctx, cmObj)).To(Succeed())
 		}
 
@@ -84,6 +100,163 @@ var _ = Describe("FakePolicy TargetConfigMaps", func() {
 			Include: []nucleusv1beta1.NonEmptyString{"kube-[system"},
 		}, []string{}, "error parsing 'include' pattern 'kube-[system': syntax error in pattern"),
 
+		// Testing with regular expressions
+		Entry("include configmaps with a regex", nucleusv1beta1.Target{
+			Include:   []nucleusv1beta1.NonEmptyString{"fa[kz]e|goo"},
+			MatchMode: nucleusv1beta1.RegexMatchMode,
+		}, []string{"default/fake", "default/faze", "default/goo"}, ""),
+		Entry("exclude configmaps with a regex", nucleusv1beta1.Target{
+			Exclude:   []nucleusv1beta1.NonEmptyString{"(kube|extension)-.*", "f.*"},
+			MatchMode: nucleusv1beta1.RegexMatchMode,
+		}, []string{"default/goo"}, ""),
+		Entry("error if a regex include entry is malformed", nucleusv1beta1.Target{
+			Include:   []nucleusv1beta1.NonEmptyString{"kube-(system"},
+			MatchMode: nucleusv1beta1.RegexMatchMode,
+		}, []string{}, "error parsing 'include' pattern 'kube-(system': "+
+			"error parsing regexp: missing closing ): `kube-(system`"),
+
+		// Testing with field selector
+		Entry("select by a field selector", nucleusv1beta1.Target{
+			FieldSelector: "metadata.name=foo",
+		}, []string{"default/foo"}, ""),
+		Entry("select by a field selector and exclude", nucleusv1beta1.Target{
+			FieldSelector: "metadata.name!=foo",
+			Exclude:       []nucleusv1beta1.NonEmptyString{"kube-*", "extension-*", "f*"},
+		}, []string{"default/goo"}, ""),
+		Entry("error if the field selector is malformed", nucleusv1beta1.Target{
+			FieldSelector: "metadata.name",
+		}, []string{}, "invalid selector: 'metadata.name'; can't understand 'metadata.name'"),
+
+		// Testing with annotation selector
+		Entry("select by an annotation existing and exclude", nucleusv1beta1.Target{
+			AnnotationSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key:      "sample-owner",
+					Operator: metav1.LabelSelectorOpExists,
+				}},
+			},
+			Exclude: []nucleusv1beta1.NonEmptyString{"f*"},
+		}, []string{
+			"default/goo",
+			"default/kube-one",
+			"default/extension-apiserver-authentication",
+			"kube-public/kube-testing",
+		}, ""),
+		Entry("select by an annotation matching a specific value", nucleusv1beta1.Target{
+			AnnotationSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"sample-owner": "https://example.com/foo",
+				},
+			},
+		}, []string{"default/foo"}, ""),
+		Entry("error if the AnnotationSelector is malformed", nucleusv1beta1.Target{
+			AnnotationSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"bad key!": "foo",
+				},
+			},
+		}, []string{}, "invalid annotation selector: annotationSelector.matchLabels: Invalid value: "+
+			"\"bad key!\": name part must consist of alphanumeric characters, '-', '_' or '.', and must "+
+			"start and end with an alphanumeric character (e.g. 'MyName',  or 'my.name',  or '123-abc', "+
+			"regex used for validation is '([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]')"),
+
+		// Testing with owner filters
+		Entry("select configmaps without an owner", nucleusv1beta1.Target{
+			NoOwner: true,
+			Include: []nucleusv1beta1.NonEmptyString{"f*"},
+		}, []string{"default/foo", "default/fake", "default/faze"}, ""),
+		Entry("select configmaps without an owner, leaving out an owned one", nucleusv1beta1.Target{
+			NoOwner: true,
+			Include: []nucleusv1beta1.NonEmptyString{"*oo"},
+		}, []string{"default/foo"}, ""),
+		Entry("select configmaps owned by a ReplicaSet", nucleusv1beta1.Target{
+			Owner: &nucleusv1beta1.OwnerSelector{Kind: "ReplicaSet"},
+		}, []string{"default/goo"}, ""),
+		Entry("select configmaps by the name of their owner", nucleusv1beta1.Target{
+			Owner: &nucleusv1beta1.OwnerSelector{Kind: "ReplicaSet", Name: "web-*"},
+		}, []string{"default/goo"}, ""),
+		Entry("select no configmaps when the owner name does not match", nucleusv1beta1.Target{
+			Owner: &nucleusv1beta1.OwnerSelector{Name: "api-*"},
+		}, []string{}, ""),
+		Entry("error if both owner filters are set", nucleusv1beta1.Target{
+			Owner:   &nucleusv1beta1.OwnerSelector{Kind: "ReplicaSet"},
+			NoOwner: true,
+		}, []string{}, "invalid owner filter: noOwner: Forbidden: may not be set at the same time as the owner"),
+		Entry("error if the owner name is malformed", nucleusv1beta1.Target{
+			Owner: &nucleusv1beta1.OwnerSelector{Name: "web-*["},
+		}, []string{}, "invalid owner filter: owner.name: Invalid value: \"web-*[\": syntax error in pattern"),
+
+		// Testing with age filters
+		Entry("select configmaps created recently", nucleusv1beta1.Target{
+			NewerThan: &metav1.Duration{Duration: 24 * time.Hour},
+			Include:   []nucleusv1beta1.NonEmptyString{"f*"},
+		}, []string{"default/foo", "default/fake", "default/faze"}, ""),
+		Entry("select configmaps created long ago", nucleusv1beta1.Target{
+			OlderThan: &metav1.Duration{Duration: 24 * time.Hour},
+		}, []string{}, ""),
+		Entry("error if the age window is empty", nucleusv1beta1.Target{
+			OlderThan: &metav1.Duration{Duration: time.Hour},
+			NewerThan: &metav1.Duration{Duration: time.Hour},
+		}, []string{}, "invalid age filter: newerThan: Invalid value: \"1h0m0s\": "+
+			"must be longer than olderThan, otherwise no objects can match"),
+
+		// Testing with field requirements
+		Entry("select by field requirements and exclude", nucleusv1beta1.Target{
+			FieldRequirements: []nucleusv1beta1.FieldRequirement{{
+				Path:     "data.foo",
+				Operator: nucleusv1beta1.FieldOpIn,
+				Values:   []string{"bar"},
+			}},
+			Exclude: []nucleusv1beta1.NonEmptyString{"f*"},
+		}, []string{
+			"default/goo",
+			"default/kube-one",
+			"default/extension-apiserver-authentication",
+			"kube-public/kube-testing",
+		}, ""),
+		Entry("error if a field requirement is missing values", nucleusv1beta1.Target{
+			FieldRequirements: []nucleusv1beta1.FieldRequirement{{
+				Path:     "data.foo",
+				Operator: nucleusv1beta1.FieldOpNotIn,
+			}},
+		}, []string{}, "invalid field requirement: fieldRequirements[0].values: "+
+			"Required value: must be specified when the operator is In or NotIn"),
+
+		// Testing with an expression
+		Entry("select by an expression", nucleusv1beta1.Target{
+			Expression: `has(object.metadata.labels) && object.metadata.labels.sample.startsWith("f")`,
+		}, []string{"default/foo", "default/fake", "default/faze"}, ""),
+		Entry("select by an expression and include", nucleusv1beta1.Target{
+			Include:    []nucleusv1beta1.NonEmptyString{"*o*"},
+			Expression: `object.metadata.namespace == "default"`,
+		}, []string{
+			"default/foo", "default/goo", "default/kube-one", "default/extension-apiserver-authentication",
+		}, ""),
+		Entry("error if the expression is not a bool", nucleusv1beta1.Target{
+			Expression: `"foo"`,
+		}, []string{}, "error compiling 'expression': the expression must evaluate to a bool, not string"),
+
+		// Testing with a namespace selector
+		Entry("select configmaps in namespaces matching a pattern", nucleusv1beta1.Target{
+			NamespaceSelector: &nucleusv1beta1.NamespaceSelector{
+				Include: []nucleusv1beta1.NonEmptyString{"kube-*"},
+			},
+		}, []string{
+			"kube-system/extension-apiserver-authentication",
+			"kube-system/kube-apiserver-legacy-service-account-token-tracking",
+			"kube-public/kube-testing",
+		}, ""),
+		Entry("select configmaps by name in namespaces matching a pattern", nucleusv1beta1.Target{
+			NamespaceSelector: &nucleusv1beta1.NamespaceSelector{
+				Include: []nucleusv1beta1.NonEmptyString{"*"},
+				Exclude: []nucleusv1beta1.NonEmptyString{"kube-*"},
+			},
+			Include: []nucleusv1beta1.NonEmptyString{"kube-*", "extension-*"},
+		}, []string{"default/kube-one", "default/extension-apiserver-authentication"}, ""),
+		Entry("an empty namespace selector matches nothing", nucleusv1beta1.Target{
+			NamespaceSelector: &nucleusv1beta1.NamespaceSelector{},
+		}, []string{}, ""),
+
 		// Testing with label selector
 		Entry("select by a label existing", nucleusv1beta1.Target{
 			LabelSelector: &metav1.LabelSelector{
@@ -219,4 +392,102 @@ var _ = Describe("FakePolicy TargetConfigMaps", func() {
 			entries,
 		)
 	})
+
+	Describe("Targets in a TargetList", Ordered, func() {
+		BeforeAll(beforeFunc)
+
+		DescribeTable("Verifying TargetConfigMapsUnion behavior",
+			func(ctx SpecContext, targets nucleusv1beta1.TargetList, desiredMatches []string, selErr string) {
+				policy := SampleFakePolicy()
+				policy.Spec.TargetConfigMapsUnion = targets
+
+				Expect(tk.CleanlyCreate(ctx, &policy)).To(Succeed())
+
+				slices.Sort(desiredMatches)
+
+				Eventually(func(g Gomega) {
+					foundPolicy := fakev1beta1.FakePolicy{}
+					g.Expect(tk.Get(ctx, testutils.ObjNN(&policy), &foundPolicy)).To(Succeed())
+					g.Expect(foundPolicy.Status.SelectionComplete).To(BeTrue())
+
+					idx, cond := foundPolicy.Status.GetCondition("UnionSelection")
+					g.Expect(idx).NotTo(Equal(-1))
+					if selErr != "" {
+						g.Expect(cond.Message).To(Equal(selErr))
+					} else {
+						g.Expect(cond.Message).To(Equal(fmt.Sprintf("%v", desiredMatches)))
+					}
+				}).Should(Succeed())
+			},
+			Entry("targets in different namespaces with their own label selectors", nucleusv1beta1.TargetList{{
+				Namespace: "default",
+				LabelSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"sample": "foo"},
+				},
+			}, {
+				Namespace: "kube-public",
+				LabelSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{{
+						Key:      "sample",
+						Operator: metav1.LabelSelectorOpExists,
+					}},
+				},
+			}}, []string{"default/foo", "kube-public/kube-testing"}, ""),
+			Entry("overlapping targets", nucleusv1beta1.TargetList{{
+				Include: []nucleusv1beta1.NonEmptyString{"f*"},
+			}, {
+				Include: []nucleusv1beta1.NonEmptyString{"fa?e", "kube-one"},
+			}}, []string{"default/fake", "default/faze", "default/foo", "default/kube-one"}, ""),
+			Entry("error if one of the targets is malformed", nucleusv1beta1.TargetList{{
+				Include: []nucleusv1beta1.NonEmptyString{"f*"},
+			}, {
+				Include: []nucleusv1beta1.NonEmptyString{"kube-[system"},
+			}}, []string{}, "error parsing 'include' pattern 'kube-[system': syntax error in pattern"),
+		)
+	})
+
+	Describe("Targets of any kind in a TargetResource", Ordered, func() {
+		BeforeAll(beforeFunc)
+
+		DescribeTable("Verifying TargetResource behavior",
+			func(ctx SpecContext, tr nucleusv1beta1.TargetResource, desiredMatches []string, reason string) {
+				policy := SampleFakePolicy()
+				policy.Spec.TargetResource = &tr
+
+				Expect(tk.CleanlyCreate(ctx, &policy)).To(Succeed())
+
+				slices.Sort(desiredMatches)
+
+				Eventually(func(g Gomega) {
+					foundPolicy := fakev1beta1.FakePolicy{}
+					g.Expect(tk.Get(ctx, testutils.ObjNN(&policy), &foundPolicy)).To(Succeed())
+					g.Expect(foundPolicy.Status.SelectionComplete).To(BeTrue())
+
+					idx, cond := foundPolicy.Status.GetCondition("ResourceSelection")
+					g.Expect(idx).NotTo(Equal(-1))
+					g.Expect(cond.Reason).To(Equal(reason))
+					if reason == "Done" {
+						g.Expect(cond.Message).To(Equal(fmt.Sprintf("%v", desiredMatches)))
+					}
+				}).Should(Succeed())
+			},
+			Entry("configmaps in a namespace", nucleusv1beta1.TargetResource{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+				Target:     nucleusv1beta1.Target{Namespace: "kube-public"},
+			}, []string{"kube-public/kube-testing"}, "Done"),
+			Entry("namespaces, ignoring the namespace", nucleusv1beta1.TargetResource{
+				APIVersion: "v1",
+				Kind:       "Namespace",
+				Target: nucleusv1beta1.Target{
+					Namespace: "default",
+					Include:   []nucleusv1beta1.NonEmptyString{"kube-p*", "default"},
+				},
+			}, []string{"default", "kube-public"}, "Done"),
+			Entry("a kind which is not installed", nucleusv1beta1.TargetResource{
+				APIVersion: "example.com/v1",
+				Kind:       "Widget",
+			}, []string{}, "KindNotFound"),
+		)
+	})
 })
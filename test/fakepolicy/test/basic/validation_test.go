@@ -55,4 +55,15 @@ var _ = Describe("FakePolicy CRD Validation", func() {
 		Entry("empty list in namespaceSelector.include", "low", "inform", []string{}, []string{"kube-*"}, true),
 		Entry("empty list in namespaceSelector.exclude", "low", "inform", []string{"*"}, []string{}, true),
 	)
+
+	It("Rejects an empty targetConfigMapsUnion", func(ctx SpecContext) {
+		policy := FromTestdata("policy_v1beta1_fakepolicy.yaml")
+
+		Expect(unstructured.SetNestedSlice(policy.Object,
+			[]interface{}{}, "spec", "targetConfigMapsUnion")).To(Succeed())
+
+		if !errors.IsInvalid(tk.CleanlyCreate(ctx, &policy)) {
+			Fail("Expected creating the policy to fail with an 'invalid' error")
+		}
+	})
 })